@@ -1,7 +1,9 @@
 package djson
 
 import (
+	"io"
 	"math"
+	"math/big"
 	"reflect"
 	"sort"
 
@@ -149,6 +151,8 @@ func (m *DA) ReplaceAt(idx int, value interface{}) *DA {
 		} else {
 			m.Element[idx] = float64(0.0)
 		}
+	case Number:
+		m.Element[idx] = t
 	case *DA:
 		m.Element[idx] = t
 	case *DO:
@@ -428,6 +432,8 @@ func (m *DA) Type(idx int) (string, bool) {
 		return "int", true
 	case float32, float64:
 		return "float", true
+	case Number:
+		return "number", true
 	case string:
 		return "string", true
 	case bool:
@@ -475,6 +481,30 @@ func (m *DA) Int(idx int) (int64, bool) {
 	return 0, false
 }
 
+func (m *DA) Uint64(idx int) (uint64, bool) {
+	if idx >= m.Size() || idx < 0 {
+		return 0, false
+	}
+
+	return getUint64Base(m.Element[idx])
+}
+
+func (m *DA) BigInt(idx int) (*big.Int, bool) {
+	if idx >= m.Size() || idx < 0 {
+		return nil, false
+	}
+
+	return getBigIntBase(m.Element[idx])
+}
+
+func (m *DA) BigFloat(idx int) (*big.Float, bool) {
+	if idx >= m.Size() || idx < 0 {
+		return nil, false
+	}
+
+	return getBigFloatBase(m.Element[idx])
+}
+
 func (m *DA) Object(idx int) (*DO, bool) {
 	if idx >= m.Size() || idx < 0 {
 		return nil, false
@@ -552,13 +582,32 @@ func (m *DA) String2(idx int) (string, bool) {
 }
 
 func (m *DA) ToStringPretty() string {
-	jsonByte, _ := json.MarshalIndent(ArrayToSlice(m), "", "   ")
-	return string(jsonByte)
+	e := newEncoder()
+	e.indent = "   "
+	if err := e.encodeArray(m, 0); err != nil {
+		return ""
+	}
+	return string(e.buf)
 }
 
 func (m *DA) ToString() string {
-	jsonByte, _ := json.Marshal(ArrayToSlice(m))
-	return string(jsonByte)
+	e := newEncoder()
+	if err := e.encodeArray(m, 0); err != nil {
+		return ""
+	}
+	return string(e.buf)
+}
+
+func (m *DA) ToStringWith(opts EncodeOptions) string {
+	b, err := encodeWith(m, opts)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (m *DA) Encode(w io.Writer, opts EncodeOptions) error {
+	return writeEncoded(w, m, opts)
 }
 
 func (m *DA) SortObject(isAsc bool, key string) bool {
@@ -843,6 +892,10 @@ func (m *DA) Equal(t *DA) bool {
 			if mFloat != tFloat {
 				return false
 			}
+		case Number:
+			if m.Element[i].(Number) != t.Element[i].(Number) {
+				return false
+			}
 		case *DO:
 			mdo := m.Element[i].(*DO)
 			tdo := t.Element[i].(*DO)
@@ -890,6 +943,8 @@ func (m *DA) Clone() *DA {
 			t.Element[i], _ = m.Int(i)
 		case float32, float64:
 			t.Element[i], _ = m.Float(i)
+		case Number:
+			t.Element[i] = m.Element[i].(Number)
 		case *DO:
 			mdo := m.Element[i].(*DO)
 			t.Element[i] = mdo.Clone()
@@ -1,6 +1,8 @@
 package djson
 
 import (
+	"io"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -24,6 +26,7 @@ const (
 	INT    = 4
 	FLOAT  = 5
 	BOOL   = 6
+	NUMBER = 7
 )
 
 type JSON struct {
@@ -33,6 +36,7 @@ type JSON struct {
 	_Int    int64
 	_Float  float64
 	_Bool   bool
+	_Number Number
 	_Type   int
 }
 
@@ -57,6 +61,8 @@ func New(v ...int) *JSON {
 			dj._Type = FLOAT
 		case BOOL:
 			dj._Type = BOOL
+		case NUMBER:
+			dj._Type = NUMBER
 		}
 	}
 
@@ -117,6 +123,29 @@ func NewObject(v ...interface{}) *JSON {
 	return dj
 }
 
+// NewOrderedObject works like NewObject but keeps the keys in the order
+// given, see NewOrderedDO.
+
+func NewOrderedObject(v ...interface{}) *JSON {
+	dj := New()
+	dj._Object = NewOrderedDO()
+	dj._Type = OBJECT
+
+	var key string
+	var ok bool
+	for idx := range v {
+		if idx%2 == 0 {
+			if key, ok = v[idx].(string); !ok {
+				return dj
+			}
+		} else {
+			dj.Put(key, v[idx])
+		}
+	}
+
+	return dj
+}
+
 func NewArray(v ...interface{}) *JSON {
 	dj := New(ARRAY)
 
@@ -229,6 +258,18 @@ func (m *JSON) Put(v ...interface{}) *JSON {
 		return m
 	}
 
+	if n, ok := v[0].(Number); ok {
+		if m._Type == NULL || m._Type == NUMBER {
+			m._Number = n
+			m._Array = nil
+			m._Object = nil
+			m._Type = NUMBER
+		} else {
+			m.PutArray(v[0]) // best effort
+		}
+		return m
+	}
+
 	if IsIntType(v[0]) {
 		if m._Type == NULL || m._Type == INT {
 			m._Int, _ = getIntBase(v[0])
@@ -314,7 +355,7 @@ func (m *JSON) Put(v ...interface{}) *JSON {
 				if m._Object == nil {
 					m._Object = NewDO()
 				}
-				for key := range t.Map {
+				for _, key := range t.Keys() {
 					m._Object.Put(key, t.Map[key])
 				}
 			} else if m._Type == NULL {
@@ -328,7 +369,7 @@ func (m *JSON) Put(v ...interface{}) *JSON {
 			if m._Object == nil {
 				m._Object = NewDO()
 			}
-			for key := range t.Map {
+			for _, key := range t.Keys() {
 				m._Object.Put(key, t.Map[key])
 			}
 		} else if m._Type == NULL {
@@ -390,7 +431,7 @@ func (m *JSON) Put(v ...interface{}) *JSON {
 			if m._Object == nil {
 				m._Object = NewDO()
 			}
-			for key := range t._Object.Map {
+			for _, key := range t._Object.Keys() {
 				m._Object.Put(key, t._Object.Map[key])
 			}
 		}
@@ -405,7 +446,7 @@ func (m *JSON) Put(v ...interface{}) *JSON {
 				if m._Object == nil {
 					m._Object = NewDO()
 				}
-				for key := range t._Object.Map {
+				for _, key := range t._Object.Keys() {
 					m._Object.Put(key, t._Object.Map[key])
 				}
 			}
@@ -492,6 +533,8 @@ func (m *JSON) Interface(key ...interface{}) interface{} {
 			return m._Int
 		case FLOAT:
 			return m._Float
+		case NUMBER:
+			return m._Number
 		case OBJECT:
 			return m._Object
 		case ARRAY:
@@ -524,7 +567,6 @@ func (m *JSON) Get(key ...interface{}) (*JSON, bool) {
 		return m, true
 	}
 
-	r := New()
 	var element interface{}
 	var retOk bool
 
@@ -543,6 +585,14 @@ func (m *JSON) Get(key ...interface{}) (*JSON, bool) {
 		return nil, false
 	}
 
+	return wrapElement(element)
+}
+
+// wrapElement returns an element of a DO or DA as JSON, sharing objects
+// and arrays.
+
+func wrapElement(element interface{}) (*JSON, bool) {
+	r := New()
 	eVal := reflect.ValueOf(element)
 
 	switch t := element.(type) {
@@ -566,6 +616,9 @@ func (m *JSON) Get(key ...interface{}) (*JSON, bool) {
 		floatVal := eVal.Float()
 		r._Float = floatVal
 		r._Type = FLOAT
+	case Number:
+		r._Number = t
+		r._Type = NUMBER
 	case DA:
 		r._Array = &t
 		r._Type = ARRAY
@@ -698,6 +751,9 @@ func (m *JSON) Int(key ...interface{}) int64 {
 			return m._Int
 		case FLOAT:
 			return int64(m._Float)
+		case NUMBER:
+			iVal, _ := getIntBase(m._Number)
+			return iVal
 		}
 
 	} else {
@@ -746,6 +802,8 @@ func (m *JSON) Bool(key ...interface{}) bool {
 			return false
 		case INT:
 			return m._Int == 1
+		case NUMBER:
+			return m._Number == "1"
 		case BOOL:
 			return m._Bool
 		}
@@ -800,6 +858,9 @@ func (m *JSON) Float(key ...interface{}) float64 {
 			return float64(m._Int)
 		case FLOAT:
 			return m._Float
+		case NUMBER:
+			fVal, _ := getFloatBase(m._Number)
+			return fVal
 		}
 
 		return 0 // zero value
@@ -898,6 +959,8 @@ func (m *JSON) ToString() string {
 		return floatStr
 	case BOOL:
 		return gov.ToString(m._Bool)
+	case NUMBER:
+		return string(m._Number)
 	case OBJECT:
 		return m._Object.ToString()
 	case ARRAY:
@@ -907,6 +970,21 @@ func (m *JSON) ToString() string {
 	return "" // zero value
 }
 
+// ToStringWith encodes the value as JSON text. Unlike ToString, a STRING
+// value is written quoted.
+
+func (m *JSON) ToStringWith(opts EncodeOptions) string {
+	b, err := encodeWith(m, opts)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (m *JSON) Encode(w io.Writer, opts EncodeOptions) error {
+	return writeEncoded(w, m, opts)
+}
+
 func (m *JSON) Rename(from, to string) bool {
 	if m._Type != OBJECT {
 		return false
@@ -979,6 +1057,9 @@ func (m *JSON) Scan() *JSON {
 	case float32, float64:
 		ret._Type = FLOAT
 		ret._Float = reflect.ValueOf(t).Float()
+	case Number:
+		ret._Type = NUMBER
+		ret._Number = t
 	case *DA:
 		ret._Type = ARRAY
 		ret._Array = t
@@ -1002,3 +1083,79 @@ func (m *JSON) Scan() *JSON {
 	return ret
 
 }
+
+// Uint64, BigInt and BigFloat read numbers without int64 / float64 loss.
+// They are most useful on values parsed with ParseOptions.PreserveNumbers.
+
+func (m *JSON) Uint64(key ...interface{}) uint64 {
+	if IsEmptyArg(key) {
+		if uVal, ok := getUint64Base(m.Interface()); ok {
+			return uVal
+		}
+		return 0
+	}
+
+	switch tkey := key[0].(type) {
+	case string:
+		if m._Type == OBJECT {
+			if uVal, ok := m._Object.Uint64(tkey); ok {
+				return uVal
+			}
+		}
+	default:
+		kint, ok := getIntBase(key[0])
+		if ok && m._Type == ARRAY {
+			if uVal, ok := m._Array.Uint64(int(kint)); ok {
+				return uVal
+			}
+		}
+	}
+
+	if len(key) >= 2 {
+		if dv, ok := getUint64Base(key[1]); ok {
+			return dv
+		}
+	}
+
+	return 0
+}
+
+func (m *JSON) BigInt(key ...interface{}) (*big.Int, bool) {
+	if IsEmptyArg(key) {
+		return getBigIntBase(m.Interface())
+	}
+
+	switch tkey := key[0].(type) {
+	case string:
+		if m._Type == OBJECT {
+			return m._Object.BigInt(tkey)
+		}
+	default:
+		kint, ok := getIntBase(key[0])
+		if ok && m._Type == ARRAY {
+			return m._Array.BigInt(int(kint))
+		}
+	}
+
+	return nil, false
+}
+
+func (m *JSON) BigFloat(key ...interface{}) (*big.Float, bool) {
+	if IsEmptyArg(key) {
+		return getBigFloatBase(m.Interface())
+	}
+
+	switch tkey := key[0].(type) {
+	case string:
+		if m._Type == OBJECT {
+			return m._Object.BigFloat(tkey)
+		}
+	default:
+		kint, ok := getIntBase(key[0])
+		if ok && m._Type == ARRAY {
+			return m._Array.BigFloat(int(kint))
+		}
+	}
+
+	return nil, false
+}
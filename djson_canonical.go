@@ -0,0 +1,284 @@
+package djson
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+// Canonical form follows RFC 8785 (JSON Canonicalization Scheme): object
+// keys sorted by UTF-16 code units, no white space, minimal string escaping
+// and numbers written as ECMAScript does. Every number is an IEEE 754
+// double, so integers beyond 2^53 are rounded just like in JavaScript.
+
+func (m *JSON) ToCanonical() ([]byte, error) {
+	return appendCanonical(nil, m)
+}
+
+func (m *JSON) CanonicalHash(alg crypto.Hash) ([]byte, error) {
+	return canonicalHash(m, alg)
+}
+
+func (m *DO) ToCanonical() ([]byte, error) {
+	return appendCanonical(nil, m)
+}
+
+func (m *DO) CanonicalHash(alg crypto.Hash) ([]byte, error) {
+	return canonicalHash(m, alg)
+}
+
+func (m *DA) ToCanonical() ([]byte, error) {
+	return appendCanonical(nil, m)
+}
+
+func (m *DA) CanonicalHash(alg crypto.Hash) ([]byte, error) {
+	return canonicalHash(m, alg)
+}
+
+func canonicalHash(v interface{}, alg crypto.Hash) ([]byte, error) {
+	if !alg.Available() {
+		return nil, errors.New("hash function not available")
+	}
+
+	b, err := appendCanonical(nil, v)
+	if err != nil {
+		return nil, err
+	}
+
+	h := alg.New()
+	h.Write(b)
+	return h.Sum(nil), nil
+}
+
+func appendCanonical(buf []byte, v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case string:
+		return appendCanonicalString(buf, t)
+	case bool:
+		return strconv.AppendBool(buf, t), nil
+	case *DO:
+		if t == nil {
+			return append(buf, "null"...), nil
+		}
+		return appendCanonicalObject(buf, t)
+	case DO:
+		return appendCanonicalObject(buf, &t)
+	case *DA:
+		if t == nil {
+			return append(buf, "null"...), nil
+		}
+		return appendCanonicalArray(buf, t)
+	case DA:
+		return appendCanonicalArray(buf, &t)
+	case *JSON:
+		if t == nil {
+			return append(buf, "null"...), nil
+		}
+		return appendCanonical(buf, t.Interface())
+	case JSON:
+		return appendCanonical(buf, t.Interface())
+	case map[string]interface{}:
+		return appendCanonicalObject(buf, MapToObject(t))
+	case Object:
+		return appendCanonicalObject(buf, MapToObject(t))
+	case []interface{}:
+		return appendCanonicalArray(buf, SliceToArray(t))
+	case Array:
+		return appendCanonicalArray(buf, SliceToArray(t))
+	case Number:
+		f, err := t.Float64()
+		if err != nil {
+			return nil, errors.New("invalid Number " + strconv.Quote(string(t)))
+		}
+		return appendCanonicalNumber(buf, f)
+	}
+
+	if IsIntType(v) || IsFloatType(v) {
+		if IsInTypes(v, "uint", "uint64") {
+			u, _ := getUint64Base(v)
+			return appendCanonicalNumber(buf, float64(u))
+		}
+
+		f, _ := getFloatBase(v)
+		return appendCanonicalNumber(buf, f)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := decodeDocument(b, ParseOptions{Strict: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return appendCanonical(buf, data)
+}
+
+func appendCanonicalObject(buf []byte, obj *DO) ([]byte, error) {
+	keys := make([]string, 0, len(obj.Map))
+	for k := range obj.Map {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i], keys[j])
+	})
+
+	var err error
+
+	buf = append(buf, '{')
+	for idx, key := range keys {
+		if idx > 0 {
+			buf = append(buf, ',')
+		}
+
+		if buf, err = appendCanonicalString(buf, key); err != nil {
+			return nil, err
+		}
+
+		buf = append(buf, ':')
+
+		if buf, err = appendCanonical(buf, obj.Map[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(buf, '}'), nil
+}
+
+func appendCanonicalArray(buf []byte, arr *DA) ([]byte, error) {
+	var err error
+
+	buf = append(buf, '[')
+	for idx := range arr.Element {
+		if idx > 0 {
+			buf = append(buf, ',')
+		}
+
+		if buf, err = appendCanonical(buf, arr.Element[idx]); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(buf, ']'), nil
+}
+
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+
+	for idx := 0; idx < len(ua) && idx < len(ub); idx++ {
+		if ua[idx] != ub[idx] {
+			return ua[idx] < ub[idx]
+		}
+	}
+
+	return len(ua) < len(ub)
+}
+
+func appendCanonicalString(buf []byte, s string) ([]byte, error) {
+	if !utf8.ValidString(s) {
+		return nil, errors.New("invalid UTF-8 in string")
+	}
+
+	buf = append(buf, '"')
+
+	start := 0
+	for idx := 0; idx < len(s); idx++ {
+		c := s[idx]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+
+		buf = append(buf, s[start:idx]...)
+
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		}
+
+		start = idx + 1
+	}
+
+	buf = append(buf, s[start:]...)
+	return append(buf, '"'), nil
+}
+
+// appendCanonicalNumber implements Number::toString from ECMA-262 for
+// finite doubles.
+
+func appendCanonicalNumber(buf []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("unsupported float value")
+	}
+
+	if f == 0 {
+		return append(buf, '0'), nil
+	}
+
+	if f < 0 {
+		buf = append(buf, '-')
+		f = -f
+	}
+
+	// shortest round-trip digits as d.ddde+x
+	sci := strconv.FormatFloat(f, 'e', -1, 64)
+	mant, exp, _ := strings.Cut(sci, "e")
+	digits := strings.Replace(mant, ".", "", 1)
+
+	e, _ := strconv.Atoi(exp)
+	k := len(digits)
+	n := e + 1
+
+	switch {
+	case k <= n && n <= 21:
+		buf = append(buf, digits...)
+		buf = append(buf, strings.Repeat("0", n-k)...)
+	case 0 < n && n <= 21:
+		buf = append(buf, digits[:n]...)
+		buf = append(buf, '.')
+		buf = append(buf, digits[n:]...)
+	case -6 < n && n <= 0:
+		buf = append(buf, "0."...)
+		buf = append(buf, strings.Repeat("0", -n)...)
+		buf = append(buf, digits...)
+	default:
+		buf = append(buf, digits[0])
+		if k > 1 {
+			buf = append(buf, '.')
+			buf = append(buf, digits[1:]...)
+		}
+		buf = append(buf, 'e')
+		if n-1 >= 0 {
+			buf = append(buf, '+')
+		}
+		buf = strconv.AppendInt(buf, int64(n-1), 10)
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,94 @@
+package djson
+
+import (
+	"crypto"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+func TestToCanonical(t *testing.T) {
+	doc := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+
+	aJson := New().Parse(doc)
+
+	result, err := aJson.ToCanonical()
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	if err != nil || string(result) != expected {
+		t.Errorf("Expected %s, but got %s (%v)", expected, result, err)
+	}
+
+	sorting := New().Parse(`{
+		"\u20ac": "Euro Sign",
+		"\r": "Carriage Return",
+		"\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"\ud83d\ude00": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"\u00f6": "Latin Small Letter O With Diaeresis"
+	}`)
+
+	result, _ = sorting.ToCanonical()
+	expected = "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\"," +
+		"\"\u20ac\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	if string(result) != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	inf := New().setDecoded(&DA{Element: []interface{}{math.Inf(1)}})
+	if _, err := inf.ToCanonical(); err == nil {
+		t.Errorf("Expected Infinity to fail")
+	}
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	cases := map[uint64]string{
+		0x0000000000000000: "0",
+		0x8000000000000000: "0",
+		0x0000000000000001: "5e-324",
+		0x8000000000000001: "-5e-324",
+		0x7fefffffffffffff: "1.7976931348623157e+308",
+		0xffefffffffffffff: "-1.7976931348623157e+308",
+		0x4340000000000000: "9007199254740992",
+		0xc340000000000000: "-9007199254740992",
+		0x4430000000000000: "295147905179352830000",
+		0x44b52d02c7e14af5: "9.999999999999997e+22",
+		0x44b52d02c7e14af6: "1e+23",
+		0x3eb0c6f7a0b5ed8d: "0.000001",
+		0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+		0x41b3de4355555555: "333333333.3333333",
+	}
+
+	for bits, expected := range cases {
+		result, err := appendCanonicalNumber(nil, math.Float64frombits(bits))
+		if err != nil || string(result) != expected {
+			t.Errorf("Expected %s, but got %s (%v)", expected, result, err)
+		}
+	}
+}
+
+func TestCanonicalHash(t *testing.T) {
+	aJson := New().Parse(`{"b": [1.0, 2], "a": "x"}`)
+	bJson, _ := New().ParseWith([]byte(`{ "a" : "x", "b" : [1, 2.0] }`), ParseOptions{Ordered: true, PreserveNumbers: true})
+
+	ha, err := aJson.CanonicalHash(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hb, _ := bJson.CanonicalHash(crypto.SHA256)
+
+	// sha256 of {"a":"x","b":[1,2]}
+	expected := "721ef82f2d6c0997bffb7a8ab3f40f8fb45b0b52ce2af3afa6b0f05efbdc317f"
+	if hex.EncodeToString(ha) != expected || hex.EncodeToString(hb) != expected {
+		t.Errorf("Expected %s, but got %x and %x", expected, ha, hb)
+	}
+
+	if _, err := aJson.CanonicalHash(crypto.MD4); err == nil {
+		t.Errorf("Expected an unavailable hash to fail")
+	}
+}
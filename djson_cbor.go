@@ -0,0 +1,661 @@
+package djson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+// CBOR (RFC 8949) maps onto djson values as follows: maps with text keys
+// become objects, arrays become arrays, and integers, floats, text strings,
+// booleans and null become the matching scalars. Integers beyond int64 are
+// decoded as Number. Byte strings, undefined, other simple values, NaN and
+// infinite floats and maps with non-text keys have no JSON equivalent and
+// fail with ErrCBORUnsupported.
+
+var ErrCBORUnsupported = errors.New("CBOR value has no JSON equivalent")
+
+// CBOROptions controls ToCBOR and ParseCBOR.
+// Deterministic sorts map keys as in RFC 8949 section 4.2.1. Integers and
+// floats always use their shortest form.
+// BigNum encodes integer Number values outside the 64-bit range as bignums
+// (tags 2 and 3) instead of floats.
+// EpochTime decodes epoch times (tag 1) as RFC 3339 strings instead of
+// numbers.
+
+type CBOROptions struct {
+	Deterministic bool
+	BigNum        bool
+	EpochTime     bool
+}
+
+const (
+	cborUint = iota
+	cborNegInt
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const cborMaxDepth = 10000
+
+func (m *JSON) ToCBOR(opts ...CBOROptions) ([]byte, error) {
+	return encodeCBOR(m, opts)
+}
+
+func (m *DO) ToCBOR(opts ...CBOROptions) ([]byte, error) {
+	return encodeCBOR(m, opts)
+}
+
+func (m *DA) ToCBOR(opts ...CBOROptions) ([]byte, error) {
+	return encodeCBOR(m, opts)
+}
+
+func (m *JSON) ParseCBOR(data []byte, opts ...CBOROptions) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	d := &cborDecoder{
+		data: data,
+	}
+	if len(opts) > 0 {
+		d.opts = opts[0]
+	}
+
+	v, err := d.decodeValue(0)
+	if err != nil {
+		return m, err
+	}
+
+	if d.pos != len(d.data) {
+		return m, d.fail("trailing data")
+	}
+
+	return m.setDecoded(v), nil
+}
+
+func encodeCBOR(v interface{}, opts []CBOROptions) ([]byte, error) {
+	e := &cborEncoder{}
+	if len(opts) > 0 {
+		e.opts = opts[0]
+	}
+
+	if err := e.encodeValue(v); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+type cborEncoder struct {
+	buf  []byte
+	opts CBOROptions
+}
+
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+}
+
+func (e *cborEncoder) encodeInt(i int64) {
+	if i < 0 {
+		e.buf = appendCBORHead(e.buf, cborNegInt, uint64(-1-i))
+	} else {
+		e.buf = appendCBORHead(e.buf, cborUint, uint64(i))
+	}
+}
+
+func (e *cborEncoder) encodeValue(v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xf6)
+	case bool:
+		if t {
+			e.buf = append(e.buf, 0xf5)
+		} else {
+			e.buf = append(e.buf, 0xf4)
+		}
+	case string:
+		if !utf8.ValidString(t) {
+			return errors.New("invalid UTF-8 in string")
+		}
+		e.buf = appendCBORHead(e.buf, cborText, uint64(len(t)))
+		e.buf = append(e.buf, t...)
+	case int, int8, int16, int32, int64:
+		i, _ := getIntBase(t)
+		e.encodeInt(i)
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := getUint64Base(t)
+		e.buf = appendCBORHead(e.buf, cborUint, u)
+	case float32:
+		e.encodeFloat(float64(t))
+	case float64:
+		e.encodeFloat(t)
+	case Number:
+		return e.encodeNumber(t)
+	case *DO:
+		if t == nil {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		return e.encodeObject(t)
+	case DO:
+		return e.encodeObject(&t)
+	case *DA:
+		if t == nil {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		return e.encodeArray(t)
+	case DA:
+		return e.encodeArray(&t)
+	case *JSON:
+		if t == nil {
+			e.buf = append(e.buf, 0xf6)
+			return nil
+		}
+		return e.encodeValue(t.Interface())
+	case JSON:
+		return e.encodeValue(t.Interface())
+	case map[string]interface{}:
+		return e.encodeObject(MapToObject(t))
+	case Object:
+		return e.encodeObject(MapToObject(t))
+	case []interface{}:
+		return e.encodeArray(SliceToArray(t))
+	case Array:
+		return e.encodeArray(SliceToArray(t))
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		data, err := decodeDocument(b, ParseOptions{Strict: true, Ordered: true})
+		if err != nil {
+			return err
+		}
+
+		return e.encodeValue(data)
+	}
+
+	return nil
+}
+
+func (e *cborEncoder) encodeNumber(n Number) error {
+	bi, ok := n.BigInt()
+	if !ok || !n.IsInt() {
+		f, err := n.Float64()
+		if err != nil {
+			return errors.New("invalid Number " + strconv.Quote(string(n)))
+		}
+		e.encodeFloat(f)
+		return nil
+	}
+
+	// negative integers are written as -1 - n
+	neg := new(big.Int)
+	if bi.Sign() < 0 {
+		neg.Neg(bi).Sub(neg, big.NewInt(1))
+	}
+
+	switch {
+	case bi.IsInt64():
+		e.encodeInt(bi.Int64())
+	case bi.IsUint64():
+		e.buf = appendCBORHead(e.buf, cborUint, bi.Uint64())
+	case bi.Sign() < 0 && neg.IsUint64():
+		e.buf = appendCBORHead(e.buf, cborNegInt, neg.Uint64())
+	case e.opts.BigNum:
+		var b []byte
+		if bi.Sign() < 0 {
+			b = neg.Bytes()
+			e.buf = appendCBORHead(e.buf, cborTag, 3)
+		} else {
+			b = bi.Bytes()
+			e.buf = appendCBORHead(e.buf, cborTag, 2)
+		}
+		e.buf = appendCBORHead(e.buf, cborBytes, uint64(len(b)))
+		e.buf = append(e.buf, b...)
+	default:
+		f, err := n.Float64()
+		if err != nil {
+			return errors.New("number " + string(n) + " out of range")
+		}
+		e.encodeFloat(f)
+	}
+
+	return nil
+}
+
+// encodeFloat writes the shortest of half, single and double precision
+// that holds f exactly.
+
+func (e *cborEncoder) encodeFloat(f float64) {
+	if math.IsNaN(f) {
+		e.buf = append(e.buf, 0xf9, 0x7e, 0x00)
+		return
+	}
+
+	f32 := float32(f)
+	if float64(f32) != f {
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xfb), math.Float64bits(f))
+		return
+	}
+
+	if h, ok := float32ToHalf(f32); ok {
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xf9), h)
+		return
+	}
+
+	e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xfa), math.Float32bits(f32))
+}
+
+func float32ToHalf(f float32) (uint16, bool) {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23) & 0xff
+	mant := bits & 0x7fffff
+
+	switch {
+	case exp == 0xff:
+		return sign | 0x7c00, mant == 0
+	case exp == 0 && mant == 0:
+		return sign, true
+	case exp == 0:
+		return 0, false
+	}
+
+	e := exp - 127
+
+	if e >= -14 && e <= 15 {
+		if mant&0x1fff != 0 {
+			return 0, false
+		}
+		return sign | uint16(e+15)<<10 | uint16(mant>>13), true
+	}
+
+	if e >= -24 && e < -14 {
+		full := mant | 1<<23
+		shift := uint(13 - 14 - e)
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(full>>shift), true
+	}
+
+	return 0, false
+}
+
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
+
+func (e *cborEncoder) encodeObject(obj *DO) error {
+	e.buf = appendCBORHead(e.buf, cborMap, uint64(len(obj.Map)))
+
+	if !e.opts.Deterministic {
+		for _, key := range obj.Keys() {
+			if err := e.encodeValue(key); err != nil {
+				return err
+			}
+			if err := e.encodeValue(obj.Map[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// sort by the bytewise order of the encoded keys
+	type entry struct {
+		key     string
+		encoded []byte
+	}
+
+	entries := make([]entry, 0, len(obj.Map))
+	for key := range obj.Map {
+		k := appendCBORHead(nil, cborText, uint64(len(key)))
+		entries = append(entries, entry{key, append(k, key...)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].encoded, entries[j].encoded) < 0
+	})
+
+	for _, each := range entries {
+		if !utf8.ValidString(each.key) {
+			return errors.New("invalid UTF-8 in string")
+		}
+		e.buf = append(e.buf, each.encoded...)
+		if err := e.encodeValue(obj.Map[each.key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *cborEncoder) encodeArray(arr *DA) error {
+	e.buf = appendCBORHead(e.buf, cborArray, uint64(len(arr.Element)))
+
+	for idx := range arr.Element {
+		if err := e.encodeValue(arr.Element[idx]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+	opts CBOROptions
+}
+
+func (d *cborDecoder) fail(msg string) error {
+	return fmt.Errorf("cbor: %s at offset %d", msg, d.pos)
+}
+
+func (d *cborDecoder) unsupported(what string, offset int) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrCBORUnsupported, what, offset)
+}
+
+// readHead returns the major type, the additional information and the
+// argument of the data item at the cursor. For indefinite lengths the
+// additional information is 31 and the argument is zero.
+
+func (d *cborDecoder) readHead() (byte, byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, d.fail("unexpected end of input")
+	}
+
+	ib := d.data[d.pos]
+	d.pos++
+
+	major, info := ib>>5, ib&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		return major, info, 0, nil
+	case info == 31 && major == cborSimple:
+		d.pos--
+		return 0, 0, 0, d.fail("unexpected break")
+	default:
+		d.pos--
+		return 0, 0, 0, d.fail("invalid additional information " + strconv.Itoa(int(info)))
+	}
+
+	if len(d.data)-d.pos < size {
+		return 0, 0, 0, d.fail("unexpected end of input")
+	}
+
+	var n uint64
+	for idx := 0; idx < size; idx++ {
+		n = n<<8 | uint64(d.data[d.pos+idx])
+	}
+	d.pos += size
+
+	return major, info, n, nil
+}
+
+func (d *cborDecoder) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == 0xff {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) decodeValue(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, d.fail("maximum depth exceeded")
+	}
+
+	start := d.pos
+
+	major, info, n, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if n > math.MaxInt64 {
+			return Number(strconv.FormatUint(n, 10)), nil
+		}
+		return int64(n), nil
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			bi := new(big.Int).SetUint64(n)
+			return Number(bi.Neg(bi).Sub(bi, big.NewInt(1)).String()), nil
+		}
+		return -1 - int64(n), nil
+	case cborBytes:
+		return nil, d.unsupported("byte string", start)
+	case cborText:
+		b, err := d.readString(cborText, info, n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, d.fail("invalid UTF-8 in text string")
+		}
+		return string(b), nil
+	case cborArray:
+		return d.decodeArray(info, n, depth)
+	case cborMap:
+		return d.decodeMap(info, n, depth)
+	case cborTag:
+		return d.decodeTag(n, depth)
+	}
+
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22:
+		return nil, nil
+	case 23:
+		return nil, d.unsupported("undefined", start)
+	case 25, 26, 27:
+		f := math.Float64frombits(n)
+		if info == 25 {
+			f = halfToFloat64(uint16(n))
+		} else if info == 26 {
+			f = float64(math.Float32frombits(uint32(n)))
+		}
+
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, d.unsupported("non-finite float", start)
+		}
+		return f, nil
+	}
+
+	return nil, d.unsupported("simple value "+strconv.FormatUint(n, 10), start)
+}
+
+// readString returns the content of a byte or text string, joining the
+// chunks of an indefinite-length string.
+
+func (d *cborDecoder) readString(major, info byte, n uint64) ([]byte, error) {
+	if info != 31 {
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, d.fail("unexpected end of input")
+		}
+		b := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return b, nil
+	}
+
+	var out []byte
+	for !d.isBreak() {
+		cmajor, cinfo, cn, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if cmajor != major || cinfo == 31 {
+			return nil, d.fail("invalid chunk in indefinite-length string")
+		}
+		b, err := d.readString(cmajor, cinfo, cn)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+
+	return out, nil
+}
+
+func (d *cborDecoder) decodeArray(info byte, n uint64, depth int) (*DA, error) {
+	arr := NewDA()
+
+	for idx := uint64(0); info == 31 || idx < n; idx++ {
+		if info == 31 && d.isBreak() {
+			break
+		}
+
+		v, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr.Element = append(arr.Element, v)
+	}
+
+	return arr, nil
+}
+
+func (d *cborDecoder) decodeMap(info byte, n uint64, depth int) (*DO, error) {
+	obj := NewOrderedDO()
+
+	for idx := uint64(0); info == 31 || idx < n; idx++ {
+		if info == 31 && d.isBreak() {
+			break
+		}
+
+		keyStart := d.pos
+		k, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			return nil, d.unsupported("non-text map key", keyStart)
+		}
+
+		v, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.set(key, v)
+	}
+
+	return obj, nil
+}
+
+func (d *cborDecoder) decodeTag(tag uint64, depth int) (interface{}, error) {
+	switch tag {
+	case 1:
+		v, err := d.decodeValue(depth + 1)
+		if err != nil || !d.opts.EpochTime {
+			return v, err
+		}
+
+		var t time.Time
+		switch tv := v.(type) {
+		case int64:
+			t = time.Unix(tv, 0)
+		case float64:
+			sec, frac := math.Modf(tv)
+			t = time.Unix(int64(sec), int64(frac*1e9))
+		default:
+			return nil, d.fail("invalid epoch time")
+		}
+		return t.UTC().Format(time.RFC3339Nano), nil
+	case 2, 3:
+		major, info, n, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if major != cborBytes {
+			return nil, d.fail("invalid bignum")
+		}
+
+		b, err := d.readString(cborBytes, info, n)
+		if err != nil {
+			return nil, err
+		}
+
+		bi := new(big.Int).SetBytes(b)
+		if tag == 3 {
+			bi.Neg(bi).Sub(bi, big.NewInt(1))
+		}
+
+		if bi.IsInt64() {
+			return bi.Int64(), nil
+		}
+		return Number(bi.String()), nil
+	}
+
+	// other tags (e.g. 0 date/time string, 55799 self-described CBOR)
+	// carry a value that is decoded as is
+	return d.decodeValue(depth + 1)
+}
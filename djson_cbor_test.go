@@ -0,0 +1,129 @@
+package djson
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCBOREncode(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(1000), "1903e8"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{int64(-1), "20"},
+		{int64(-1000), "3903e7"},
+		{0.0, "f90000"},
+		{math.Copysign(0, -1), "f98000"},
+		{1.0, "f93c00"},
+		{1.1, "fb3ff199999999999a"},
+		{65504.0, "f97bff"},
+		{100000.0, "fa47c35000"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{5.960464477539063e-8, "f90001"},
+		{0.00006103515625, "f90400"},
+		{-4.1, "fbc010666666666666"},
+		{math.Inf(1), "f97c00"},
+		{math.NaN(), "f97e00"},
+		{false, "f4"},
+		{nil, "f6"},
+		{"", "60"},
+		{"ü", "62c3bc"},
+		{Number("-18446744073709551616"), "3bffffffffffffffff"},
+		{Number("18446744073709551616"), "fa5f800000"},
+		{Array{int64(1), Array{int64(2), int64(3)}}, "8201820203"},
+	}
+
+	for _, each := range cases {
+		b, err := encodeCBOR(each.value, nil)
+		if err != nil || hex.EncodeToString(b) != each.expected {
+			t.Errorf("Expected %s for %v, but got %x (%v)", each.expected, each.value, b, err)
+		}
+	}
+
+	b, _ := encodeCBOR(Number("18446744073709551616"), []CBOROptions{{BigNum: true}})
+	if result := hex.EncodeToString(b); result != "c249010000000000000000" {
+		t.Errorf("Expected c249010000000000000000, but got %s", result)
+	}
+
+	b, _ = encodeCBOR(Number("-18446744073709551617"), []CBOROptions{{BigNum: true}})
+	if result := hex.EncodeToString(b); result != "c349010000000000000000" {
+		t.Errorf("Expected c349010000000000000000, but got %s", result)
+	}
+}
+
+func TestCBORObject(t *testing.T) {
+	aJson, _ := New().ParseWith([]byte(`{"b": [2, 3], "aa": null, "a": 1}`), ParseOptions{Ordered: true})
+
+	b, err := aJson.ToCBOR()
+	if err != nil || hex.EncodeToString(b) != "a36162820203626161f6616101" {
+		t.Errorf("Expected insertion order, but got %x (%v)", b, err)
+	}
+
+	b, _ = aJson.ToCBOR(CBOROptions{Deterministic: true})
+	if result := hex.EncodeToString(b); result != "a36161016162820203626161f6" {
+		t.Errorf("Expected a36161016162820203626161f6, but got %s", result)
+	}
+
+	bJson, err := New().ParseCBOR(b)
+	if err != nil || !bJson.Equal(aJson) {
+		t.Errorf("Expected round trip, but got %s (%v)", bJson.ToString(), err)
+	}
+}
+
+func TestCBORDecode(t *testing.T) {
+	cases := []struct {
+		data     string
+		expected string
+	}{
+		{"1bffffffffffffffff", "18446744073709551615"},
+		{"3bffffffffffffffff", "-18446744073709551616"},
+		{"c249010000000000000000", "18446744073709551616"},
+		{"f97bff", "65504"},
+		{"fa47c35000", "100000"},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", "[1,[2,3],[4,5]]"},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"c11a514b67b0", "1363896240"},
+		{"d9d9f7a0", "{}"},
+	}
+
+	for _, each := range cases {
+		data, _ := hex.DecodeString(each.data)
+
+		aJson, err := New().ParseCBOR(data)
+		if err != nil || aJson.ToString() != each.expected {
+			t.Errorf("Expected %s for %s, but got %s (%v)", each.expected, each.data, aJson.ToString(), err)
+		}
+	}
+
+	data, _ := hex.DecodeString("c1fb41d452d9ec200000")
+	aJson, _ := New().ParseCBOR(data, CBOROptions{EpochTime: true})
+	if result := aJson.ToString(); result != "2013-03-21T20:04:00.5Z" {
+		t.Errorf("Expected 2013-03-21T20:04:00.5Z, but got %s", result)
+	}
+
+	// f97e00 is NaN, fa7f800000 +Inf and fbfff0000000000000 -Inf
+	for _, each := range []string{"4401020304", "f7", "f0", "a1016161", "a16161f97e00", "fa7f800000", "fbfff0000000000000"} {
+		data, _ := hex.DecodeString(each)
+		if _, err := New().ParseCBOR(data); !errors.Is(err, ErrCBORUnsupported) {
+			t.Errorf("Expected ErrCBORUnsupported for %s, but got %v", each, err)
+		}
+	}
+
+	for _, each := range []string{"", "1b0000", "8301", "0102", "ff", "62c3"} {
+		data, _ := hex.DecodeString(each)
+		if _, err := New().ParseCBOR(data); err == nil || errors.Is(err, ErrCBORUnsupported) {
+			t.Errorf("Expected malformed error for %s, but got %v", each, err)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package djson
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions controls ParseCSV.
+// Comma is the field delimiter (',' if zero).
+// InferTypes turns cells that look like integers, numbers or true / false
+// into INT, FLOAT and BOOL values; otherwise every cell is a STRING.
+// EmptyAsNull turns empty cells into null instead of "".
+// Unflatten rebuilds nested values from dotted column names, the inverse
+// of ToCSV: "a.b" becomes {"a": {"b": ...}} and "a.0" {"a": [...]}.
+
+type CSVOptions struct {
+	Comma       rune
+	InferTypes  bool
+	EmptyAsNull bool
+	Unflatten   bool
+}
+
+// ToCSV writes an ARRAY of OBJECTs as CSV with a header row, quoting
+// fields as in RFC 4180. Nested objects and arrays are flattened into
+// dotted columns such as "address.city" and "tags.0". Without columns,
+// every flattened key is written in the order it first appears. A column
+// that names a nested object or array gets its JSON text, and a missing
+// value an empty cell.
+
+func (m *JSON) ToCSV(w io.Writer, columns ...string) error {
+	if !m.IsArray() {
+		return errors.New("not Array")
+	}
+
+	rows := make([]*DO, 0, len(m._Array.Element))
+	for _, each := range m._Array.Element {
+		v, err := normalizeValue(each)
+		if err != nil {
+			return err
+		}
+
+		row, ok := v.(*DO)
+		if !ok {
+			return errors.New("not Object")
+		}
+		rows = append(rows, row)
+	}
+
+	if len(columns) == 0 {
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			for _, column := range flattenColumns(row, "") {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for idx, column := range columns {
+			cell, err := csvCell(lookupColumn(row, column))
+			if err != nil {
+				return err
+			}
+			record[idx] = cell
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenColumns lists the dotted names of the scalar leaves of v. Empty
+// objects and arrays count as leaves.
+
+func flattenColumns(v interface{}, prefix string) []string {
+	v, _ = normalizeValue(v)
+
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	var columns []string
+
+	switch t := v.(type) {
+	case *DO:
+		if len(t.Map) > 0 {
+			for _, key := range t.Keys() {
+				columns = append(columns, flattenColumns(t.Map[key], join(key))...)
+			}
+			return columns
+		}
+	case *DA:
+		if len(t.Element) > 0 {
+			for idx := range t.Element {
+				columns = append(columns, flattenColumns(t.Element[idx], join(strconv.Itoa(idx)))...)
+			}
+			return columns
+		}
+	}
+
+	return []string{prefix}
+}
+
+// lookupColumn finds the value of a dotted column. A key that contains
+// dots itself is matched before the column is split.
+
+func lookupColumn(v interface{}, column string) interface{} {
+	v, _ = normalizeValue(v)
+
+	switch t := v.(type) {
+	case *DO:
+		if each, ok := t.Map[column]; ok {
+			return each
+		}
+
+		for idx := strings.IndexByte(column, '.'); idx >= 0; idx = nextDot(column, idx) {
+			if each, ok := t.Map[column[:idx]]; ok {
+				if found := lookupColumn(each, column[idx+1:]); found != nil {
+					return found
+				}
+			}
+		}
+	case *DA:
+		head, rest, nested := strings.Cut(column, ".")
+
+		idx, err := strconv.Atoi(head)
+		if err != nil || idx < 0 || idx >= len(t.Element) {
+			return nil
+		}
+
+		if !nested {
+			return t.Element[idx]
+		}
+		return lookupColumn(t.Element[idx], rest)
+	}
+
+	return nil
+}
+
+func nextDot(s string, after int) int {
+	idx := strings.IndexByte(s[after+1:], '.')
+	if idx < 0 {
+		return -1
+	}
+	return after + 1 + idx
+}
+
+func csvCell(v interface{}) (string, error) {
+	v, err := normalizeValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	}
+
+	b, err := encodeWith(v, EncodeOptions{DisableHTMLEscape: true})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// ParseCSV reads CSV with a header row into an ARRAY of OBJECTs whose keys
+// follow the header order.
+
+func (m *JSON) ParseCSV(r io.Reader, opts CSVOptions) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return m, errors.New("missing CSV header")
+	}
+	if err != nil {
+		return m, err
+	}
+
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	seen := make(map[string]bool, len(header))
+	for _, column := range header {
+		if seen[column] {
+			return m, errors.New("duplicate CSV column " + strconv.Quote(column))
+		}
+		seen[column] = true
+	}
+
+	arr := NewDA()
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+
+		row := NewOrderedDO()
+
+		for idx, column := range header {
+			v := csvValue(record[idx], opts)
+
+			if !opts.Unflatten {
+				row.set(column, v)
+				continue
+			}
+
+			if err := setColumn(row, strings.Split(column, "."), v); err != nil {
+				return m, err
+			}
+		}
+
+		if opts.Unflatten {
+			arraysFromIndexes(row)
+		}
+
+		arr.Element = append(arr.Element, row)
+	}
+
+	return m.setDecoded(arr), nil
+}
+
+func csvValue(cell string, opts CSVOptions) interface{} {
+	if cell == "" && opts.EmptyAsNull {
+		return nil
+	}
+
+	if !opts.InferTypes {
+		return cell
+	}
+
+	switch cell {
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if !Number(cell).IsValid() {
+		return cell
+	}
+
+	if i, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(cell, 64); err == nil {
+		return f
+	}
+
+	return cell
+}
+
+func setColumn(obj *DO, path []string, v interface{}) error {
+	if len(path) == 1 {
+		if _, ok := obj.Map[path[0]]; ok {
+			return errors.New("conflicting CSV column " + strconv.Quote(path[0]))
+		}
+		obj.set(path[0], v)
+		return nil
+	}
+
+	child, ok := obj.Map[path[0]].(*DO)
+	if !ok {
+		if _, exists := obj.Map[path[0]]; exists {
+			return errors.New("conflicting CSV column " + strconv.Quote(path[0]))
+		}
+		child = NewOrderedDO()
+		obj.set(path[0], child)
+	}
+
+	return setColumn(child, path[1:], v)
+}
+
+// arraysFromIndexes turns nested objects whose keys are exactly 0..n-1
+// into arrays, so that "tags.0" and "tags.1" give back "tags".
+
+func arraysFromIndexes(obj *DO) {
+	for _, key := range obj.Keys() {
+		child, ok := obj.Map[key].(*DO)
+		if !ok {
+			continue
+		}
+
+		arraysFromIndexes(child)
+		if arr := indexedArray(child); arr != nil {
+			obj.Map[key] = arr
+		}
+	}
+}
+
+func indexedArray(obj *DO) *DA {
+	elems := make([]interface{}, len(obj.Map))
+	for key, v := range obj.Map {
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(elems) || strconv.Itoa(idx) != key {
+			return nil
+		}
+		elems[idx] = v
+	}
+
+	if len(elems) == 0 {
+		return nil
+	}
+
+	return &DA{Element: elems}
+}
@@ -0,0 +1,116 @@
+package djson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestToCSV(t *testing.T) {
+	aJson, err := New().ParseWith([]byte(`[
+		{"id": 1, "name": "Kim, Minsu", "address": {"city": "Seoul", "zip": "04524"}, "tags": ["a", "b"], "vip": true},
+		{"id": 2, "name": "say \"hi\"", "address": {"city": "Busan"}, "score": 9.5, "memo": null}
+	]`), ParseOptions{Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := aJson.ToCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "id,name,address.city,address.zip,tags.0,tags.1,vip,score,memo\n" +
+		"1,\"Kim, Minsu\",Seoul,04524,a,b,true,,\n" +
+		"2,\"say \"\"hi\"\"\",Busan,,,,,9.5,\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	buf.Reset()
+	if err := aJson.ToCSV(&buf, "name", "address", "tags.1", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "name,address,tags.1,missing\n" +
+		"\"Kim, Minsu\",\"{\"\"city\"\":\"\"Seoul\"\",\"\"zip\"\":\"\"04524\"\"}\",b,\n" +
+		"\"say \"\"hi\"\"\",\"{\"\"city\"\":\"\"Busan\"\"}\",,\n"
+	if result := buf.String(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if err := New().Put(Object{"a": 1}).ToCSV(&buf); err == nil {
+		t.Errorf("Expected error for an object")
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	doc := "\ufeffid,name,score,vip,zip,memo\n1,\"Kim, Minsu\",9.5,true,04524,\n2,\"multi\nline\",-3,FALSE,,x\n"
+
+	aJson, err := New().ParseCSV(strings.NewReader(doc), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"id":"1","name":"Kim, Minsu","score":"9.5","vip":"true","zip":"04524","memo":""},` +
+		`{"id":"2","name":"multi\nline","score":"-3","vip":"FALSE","zip":"","memo":"x"}]`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	bJson, err := New().ParseCSV(strings.NewReader(doc), CSVOptions{InferTypes: true, EmptyAsNull: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `[{"id":1,"name":"Kim, Minsu","score":9.5,"vip":true,"zip":"04524","memo":null},` +
+		`{"id":2,"name":"multi\nline","score":-3,"vip":false,"zip":null,"memo":"x"}]`
+	if result := bJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if result := bJson.TypePath(`[0]["score"]`); result != "float" {
+		t.Errorf("Expected float, but got %s", result)
+	}
+
+	cJson, err := New().ParseCSV(strings.NewReader("id;a.b;a.c.0;a.c.1\n1;x;y;z\n"), CSVOptions{Comma: ';', Unflatten: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `[{"id":"1","a":{"b":"x","c":["y","z"]}}]`
+	if result := cJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	for _, each := range []string{"", "a,a\n1,2\n", "a,b\n1\n"} {
+		if _, err := New().ParseCSV(strings.NewReader(each), CSVOptions{}); err == nil {
+			t.Errorf("Expected error for %q", each)
+		}
+	}
+
+	if _, err := New().ParseCSV(strings.NewReader("a,a.b\n1,2\n"), CSVOptions{Unflatten: true}); err == nil {
+		t.Errorf("Expected error for conflicting columns")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	aJson, err := New().ParseWith([]byte(`[{"id":1,"user":{"name":"lee","roles":["admin","dev"]},"ok":false}]`), ParseOptions{Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := aJson.ToCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	bJson, err := New().ParseCSV(&buf, CSVOptions{InferTypes: true, Unflatten: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result, expected := bJson.ToString(), aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+}
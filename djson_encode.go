@@ -0,0 +1,320 @@
+package djson
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+// encoder writes DO / DA trees directly so that ordered objects keep their
+// key order. The output matches json.Marshal and json.MarshalIndent.
+
+type encoder struct {
+	buf        []byte
+	prefix     string
+	indent     string
+	escapeHTML bool
+	asciiOnly  bool
+	sortKeys   bool
+}
+
+func newEncoder() *encoder {
+	return &encoder{
+		escapeHTML: true,
+	}
+}
+
+// EncodeOptions controls ToStringWith and Encode. The zero value gives the
+// same output as ToString.
+// Prefix and Indent work as in json.MarshalIndent.
+// DisableHTMLEscape writes <, > and & as is instead of \u003c etc.
+// ASCIIOnly escapes every non-ASCII character as \uXXXX.
+// SortKeys writes object keys in sorted order even for ordered objects.
+
+type EncodeOptions struct {
+	Prefix            string
+	Indent            string
+	DisableHTMLEscape bool
+	ASCIIOnly         bool
+	SortKeys          bool
+}
+
+func newEncoderWith(opts EncodeOptions) *encoder {
+	return &encoder{
+		prefix:     opts.Prefix,
+		indent:     opts.Indent,
+		escapeHTML: !opts.DisableHTMLEscape,
+		asciiOnly:  opts.ASCIIOnly,
+		sortKeys:   opts.SortKeys,
+	}
+}
+
+func encodeWith(v interface{}, opts EncodeOptions) ([]byte, error) {
+	e := newEncoderWith(opts)
+	if err := e.encodeValue(v, 0); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+func writeEncoded(w io.Writer, v interface{}, opts EncodeOptions) error {
+	b, err := encodeWith(v, opts)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (e *encoder) newline(depth int) {
+	if e.indent == "" && e.prefix == "" {
+		return
+	}
+
+	e.buf = append(e.buf, '\n')
+	e.buf = append(e.buf, e.prefix...)
+	for idx := 0; idx < depth; idx++ {
+		e.buf = append(e.buf, e.indent...)
+	}
+}
+
+func (e *encoder) encodeValue(v interface{}, depth int) error {
+	switch t := v.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case string:
+		e.encodeString(t)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, t)
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int8:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int16:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int32:
+		e.buf = strconv.AppendInt(e.buf, int64(t), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, t, 10)
+	case uint:
+		e.buf = strconv.AppendUint(e.buf, uint64(t), 10)
+	case uint8:
+		e.buf = strconv.AppendUint(e.buf, uint64(t), 10)
+	case uint16:
+		e.buf = strconv.AppendUint(e.buf, uint64(t), 10)
+	case uint32:
+		e.buf = strconv.AppendUint(e.buf, uint64(t), 10)
+	case uint64:
+		e.buf = strconv.AppendUint(e.buf, t, 10)
+	case float32:
+		return e.encodeFloat(float64(t), 32)
+	case float64:
+		return e.encodeFloat(t, 64)
+	case Number:
+		if !t.IsValid() {
+			return errors.New("invalid Number " + strconv.Quote(string(t)))
+		}
+		e.buf = append(e.buf, t...)
+	case *DO:
+		return e.encodeObject(t, depth)
+	case DO:
+		return e.encodeObject(&t, depth)
+	case *DA:
+		return e.encodeArray(t, depth)
+	case DA:
+		return e.encodeArray(&t, depth)
+	case *JSON:
+		if t == nil {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		return e.encodeValue(t.Interface(), depth)
+	case JSON:
+		return e.encodeValue(t.Interface(), depth)
+	case map[string]interface{}:
+		return e.encodeObject(MapToObject(t), depth)
+	case Object:
+		return e.encodeObject(MapToObject(t), depth)
+	case []interface{}:
+		return e.encodeArray(SliceToArray(t), depth)
+	case Array:
+		return e.encodeArray(SliceToArray(t), depth)
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		e.buf = append(e.buf, b...)
+	}
+
+	return nil
+}
+
+func (e *encoder) encodeFloat(f float64, bits int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return errors.New("unsupported float value")
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) ||
+			bits == 64 && (abs < 1e-6 || abs >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	e.buf = strconv.AppendFloat(e.buf, f, format, -1, bits)
+	return nil
+}
+
+func (e *encoder) encodeObject(obj *DO, depth int) error {
+	if obj == nil || len(obj.Map) == 0 {
+		e.buf = append(e.buf, "{}"...)
+		return nil
+	}
+
+	e.buf = append(e.buf, '{')
+
+	keys := obj.Keys()
+	if e.sortKeys && obj.ordered {
+		keys = sortedKeys(obj.Map)
+	}
+
+	for idx, key := range keys {
+		if idx > 0 {
+			e.buf = append(e.buf, ',')
+		}
+
+		e.newline(depth + 1)
+		e.encodeString(key)
+
+		if e.indent == "" && e.prefix == "" {
+			e.buf = append(e.buf, ':')
+		} else {
+			e.buf = append(e.buf, ':', ' ')
+		}
+
+		if err := e.encodeValue(obj.Map[key], depth+1); err != nil {
+			return err
+		}
+	}
+
+	e.newline(depth)
+	e.buf = append(e.buf, '}')
+
+	return nil
+}
+
+func (e *encoder) encodeArray(arr *DA, depth int) error {
+	if arr == nil || len(arr.Element) == 0 {
+		e.buf = append(e.buf, "[]"...)
+		return nil
+	}
+
+	e.buf = append(e.buf, '[')
+
+	for idx := range arr.Element {
+		if idx > 0 {
+			e.buf = append(e.buf, ',')
+		}
+
+		e.newline(depth + 1)
+
+		if err := e.encodeValue(arr.Element[idx], depth+1); err != nil {
+			return err
+		}
+	}
+
+	e.newline(depth)
+	e.buf = append(e.buf, ']')
+
+	return nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+func (e *encoder) encodeString(s string) {
+	e.buf = append(e.buf, '"')
+
+	start := 0
+	for idx := 0; idx < len(s); {
+		c := s[idx]
+
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (!e.escapeHTML || (c != '<' && c != '>' && c != '&')) {
+				idx++
+				continue
+			}
+
+			e.buf = append(e.buf, s[start:idx]...)
+
+			switch c {
+			case '"', '\\':
+				e.buf = append(e.buf, '\\', c)
+			case '\n':
+				e.buf = append(e.buf, '\\', 'n')
+			case '\r':
+				e.buf = append(e.buf, '\\', 'r')
+			case '\t':
+				e.buf = append(e.buf, '\\', 't')
+			default:
+				e.buf = append(e.buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+
+			idx++
+			start = idx
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[idx:])
+
+		if r == utf8.RuneError && size == 1 {
+			e.buf = append(e.buf, s[start:idx]...)
+			e.buf = append(e.buf, `\ufffd`...)
+			idx += size
+			start = idx
+			continue
+		}
+
+		if r == '\u2028' || r == '\u2029' || e.asciiOnly {
+			e.buf = append(e.buf, s[start:idx]...)
+			if r >= 0x10000 {
+				r1, r2 := utf16.EncodeRune(r)
+				e.appendRuneEscape(r1)
+				e.appendRuneEscape(r2)
+			} else {
+				e.appendRuneEscape(r)
+			}
+			idx += size
+			start = idx
+			continue
+		}
+
+		idx += size
+	}
+
+	e.buf = append(e.buf, s[start:]...)
+	e.buf = append(e.buf, '"')
+}
+
+func (e *encoder) appendRuneEscape(r rune) {
+	e.buf = append(e.buf, '\\', 'u', hexDigits[r>>12&0xf], hexDigits[r>>8&0xf], hexDigits[r>>4&0xf], hexDigits[r&0xf])
+}
+
+func sortedKeys(dmap map[string]interface{}) []string {
+	keys := make([]string, 0, len(dmap))
+	for k := range dmap {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,48 @@
+package djson
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeOptions(t *testing.T) {
+	aJson, err := New().ParseWith([]byte(`{"b": "<a&b>", "a": "café 😀", "c": [1, {"z": 1, "y": 2}]}`), ParseOptions{Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"b":"\u003ca\u0026b\u003e","a":"café 😀","c":[1,{"z":1,"y":2}]}`
+	if result := aJson.ToStringWith(EncodeOptions{}); result != expected || aJson.ToString() != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	expected = `{"b":"<a&b>","a":"caf\u00e9 \ud83d\ude00","c":[1,{"z":1,"y":2}]}`
+	if result := aJson.ToStringWith(EncodeOptions{DisableHTMLEscape: true, ASCIIOnly: true}); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	expected = `{"a":"café 😀","b":"\u003ca\u0026b\u003e","c":[1,{"y":2,"z":1}]}`
+	if result := aJson.ToStringWith(EncodeOptions{SortKeys: true}); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	arr, _ := aJson.Array("c")
+	expected = "[\n>\t1,\n>\t{\n>\t\t\"z\": 1,\n>\t\t\"y\": 2\n>\t}\n>]"
+	if result := arr.ToStringWith(EncodeOptions{Prefix: ">", Indent: "\t"}); result != expected {
+		t.Errorf("Expected %q, but got %q", expected, result)
+	}
+
+	if result := NewString("x<y").ToStringWith(EncodeOptions{}); result != `"x\u003cy"` {
+		t.Errorf("Expected quoted string, but got %s", result)
+	}
+
+	var buf bytes.Buffer
+	if err := aJson.Encode(&buf, EncodeOptions{Indent: "  ", SortKeys: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "{\n  \"a\": \"café 😀\",\n  \"b\": \"\\u003ca\\u0026b\\u003e\",\n  \"c\": [\n    1,\n    {\n      \"y\": 2,\n      \"z\": 1\n    }\n  ]\n}"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buf.String())
+	}
+}
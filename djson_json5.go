@@ -0,0 +1,241 @@
+package djson
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Relaxed (JSON5 / JSONC) input adds the following to the JSON grammar:
+// line and block comments, trailing commas in objects and arrays,
+// single-quoted strings, identifier keys, the extra JSON5 string escapes,
+// hexadecimal numbers, leading or trailing decimal points, a leading plus
+// sign and the Infinity / NaN literals. JSON has no Infinity or NaN, so
+// they become null, as JSON.stringify does.
+
+// skipRelaxedSpace skips one comment or JSON5 white space character at the
+// cursor and reports whether anything was consumed. An unterminated block
+// comment is left in place so that the caller fails on it.
+
+func (m *parser) skipRelaxedSpace() bool {
+	rest := m.data[m.pos:]
+
+	switch {
+	case bytes.HasPrefix(rest, []byte("//")):
+		m.pos += 2
+		for m.pos < len(m.data) && m.data[m.pos] != '\n' && m.data[m.pos] != '\r' {
+			m.pos++
+		}
+		return true
+	case bytes.HasPrefix(rest, []byte("/*")):
+		end := bytes.Index(rest[2:], []byte("*/"))
+		if end < 0 {
+			return false
+		}
+		m.pos += end + 4
+		return true
+	case rest[0] == '\v' || rest[0] == '\f':
+		m.pos++
+		return true
+	case rest[0] >= utf8.RuneSelf:
+		r, size := utf8.DecodeRune(rest)
+		if r == '\uFEFF' || r == '\u2028' || r == '\u2029' || unicode.Is(unicode.Zs, r) {
+			m.pos += size
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIdentRune(r rune, first bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) {
+		return true
+	}
+
+	if first {
+		return false
+	}
+
+	return unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc) || r == '\u200C' || r == '\u200D'
+}
+
+func (m *parser) parseIdentifier() (string, error) {
+	start := m.pos
+
+	for m.pos < len(m.data) {
+		r, size := utf8.DecodeRune(m.data[m.pos:])
+		if !isIdentRune(r, m.pos == start) {
+			break
+		}
+		m.pos += size
+	}
+
+	if m.pos == start {
+		return "", m.failAtCursor("looking for beginning of object key")
+	}
+
+	return string(m.data[start:m.pos]), nil
+}
+
+// parseRelaxedEscape handles the escapes JSON5 adds on top of JSON. The
+// cursor is on the character following the backslash.
+
+func (m *parser) parseRelaxedEscape(c byte) error {
+	switch {
+	case c == 'v':
+		m.buf = append(m.buf, '\v')
+	case c == '0' && (m.pos+1 >= len(m.data) || m.data[m.pos+1] < '0' || m.data[m.pos+1] > '9'):
+		m.buf = append(m.buf, 0)
+	case c >= '0' && c <= '9':
+		return m.failAtCursor("in string escape code")
+	case c == 'x':
+		m.pos++
+		r, err := m.parseHex(2, "in \\x escape")
+		if err != nil {
+			return err
+		}
+		m.buf = utf8.AppendRune(m.buf, r)
+		return nil
+	case c == '\r':
+		m.pos++
+		if m.pos < len(m.data) && m.data[m.pos] == '\n' {
+			m.pos++
+		}
+		return nil
+	case c == '\n':
+	case c >= utf8.RuneSelf:
+		r, size := utf8.DecodeRune(m.data[m.pos:])
+		if r != '\u2028' && r != '\u2029' {
+			m.buf = append(m.buf, m.data[m.pos:m.pos+size]...)
+		}
+		m.pos += size
+		return nil
+	default:
+		m.buf = append(m.buf, c)
+	}
+
+	m.pos++
+	return nil
+}
+
+// parseRelaxedNumber reads a JSON5 number. The literal is rewritten into
+// JSON form, so preserved numbers stay valid Number values.
+
+func (m *parser) parseRelaxedNumber() (interface{}, error) {
+	start := m.pos
+	neg := false
+
+	if c := m.data[m.pos]; c == '+' || c == '-' {
+		neg = c == '-'
+		m.pos++
+	}
+
+	rest := m.data[m.pos:]
+
+	switch {
+	case bytes.HasPrefix(rest, []byte("Infinity")):
+		m.pos += len("Infinity")
+		return nil, nil
+	case bytes.HasPrefix(rest, []byte("NaN")):
+		m.pos += len("NaN")
+		return nil, nil
+	case len(rest) > 1 && rest[0] == '0' && (rest[1] == 'x' || rest[1] == 'X'):
+		return m.parseHexNumber(neg, start)
+	}
+
+	lit := make([]byte, 0, 24)
+	if neg {
+		lit = append(lit, '-')
+	}
+
+	intStart := m.pos
+	if m.pos < len(m.data) && m.data[m.pos] == '0' {
+		m.pos++
+	} else {
+		m.skipDigits()
+	}
+
+	hasInt := m.pos > intStart
+	if hasInt {
+		lit = append(lit, m.data[intStart:m.pos]...)
+	} else {
+		lit = append(lit, '0')
+	}
+
+	isFloat := false
+
+	if m.pos < len(m.data) && m.data[m.pos] == '.' {
+		isFloat = true
+		m.pos++
+
+		fracStart := m.pos
+		if !m.skipDigits() {
+			if !hasInt {
+				return nil, m.failAtCursor("after decimal point in numeric literal")
+			}
+			lit = append(lit, '.', '0')
+		} else {
+			lit = append(lit, '.')
+			lit = append(lit, m.data[fracStart:m.pos]...)
+		}
+	} else if !hasInt {
+		return nil, m.failAtCursor("in numeric literal")
+	}
+
+	if m.pos < len(m.data) && (m.data[m.pos] == 'e' || m.data[m.pos] == 'E') {
+		isFloat = true
+		expStart := m.pos
+		m.pos++
+		if m.pos < len(m.data) && (m.data[m.pos] == '+' || m.data[m.pos] == '-') {
+			m.pos++
+		}
+		if !m.skipDigits() {
+			return nil, m.failAtCursor("in exponent of numeric literal")
+		}
+		lit = append(lit, m.data[expStart:m.pos]...)
+	}
+
+	return m.numberValue(string(lit), isFloat, start)
+}
+
+func (m *parser) parseHexNumber(neg bool, start int) (interface{}, error) {
+	m.pos += 2 // 0x
+
+	digitStart := m.pos
+	for m.pos < len(m.data) && isHexDigit(m.data[m.pos]) {
+		m.pos++
+	}
+
+	if m.pos == digitStart {
+		return nil, m.failAtCursor("in hexadecimal numeric literal")
+	}
+
+	bi, _ := new(big.Int).SetString(string(m.data[digitStart:m.pos]), 16)
+	if neg {
+		bi.Neg(bi)
+	}
+
+	if m.preserveNumbers || m.precise && !bi.IsInt64() {
+		return Number(bi.String()), nil
+	}
+
+	if bi.IsInt64() {
+		return bi.Int64(), nil
+	}
+
+	f, _ := new(big.Float).SetInt(bi).Float64()
+	if math.IsInf(f, 0) {
+		lit := m.data[start:m.pos]
+		m.pos = start
+		return nil, m.fail("number %s out of range", lit)
+	}
+
+	return f, nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,135 @@
+package djson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseRelaxed(t *testing.T) {
+	doc := `
+	// service config
+	{
+		name: 'api-server',   /* single quotes */
+		$port: 0x1F90,
+		ratio: .5,
+		retry: +3,
+		limit: 10.,
+		"tags": ['a', "b", ],
+		nested: {
+			enabled: true, // trailing comma below
+		},
+		escapes: 'it\'s \x41\
+B',
+	}
+	/* end */`
+
+	aJson, err := New().ParseRelaxed(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := aJson.String("name"); result != "api-server" {
+		t.Errorf("Expected api-server, but got %s", result)
+	}
+
+	if result := aJson.Int("$port"); result != 8080 {
+		t.Errorf("Expected 8080, but got %d", result)
+	}
+
+	if result := aJson.Float("ratio"); result != 0.5 {
+		t.Errorf("Expected 0.5, but got %v", result)
+	}
+
+	if result := aJson.Int("retry"); result != 3 {
+		t.Errorf("Expected 3, but got %d", result)
+	}
+
+	if result := aJson.Type("limit"); result != "float" {
+		t.Errorf("Expected float, but got %s", result)
+	}
+
+	if result := aJson.StringPath(`["tags"]`); result != `["a","b"]` {
+		t.Errorf("Expected [\"a\",\"b\"], but got %s", result)
+	}
+
+	if result := aJson.BoolPath(`["nested"]["enabled"]`); !result {
+		t.Errorf("Expected nested.enabled to be true")
+	}
+
+	if result := aJson.String("escapes"); result != "it's AB" {
+		t.Errorf("Expected it's AB, but got %s", result)
+	}
+}
+
+func TestParseRelaxedNumbers(t *testing.T) {
+	aJson, err := New().ParseRelaxed(`[Infinity, -Infinity, NaN, -0x10, 0xFFFFFFFFFFFFFFFF]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	arr, _ := aJson.Array()
+
+	for idx := 0; idx < 3; idx++ {
+		if result := arr.Type(idx); result != "null" {
+			t.Errorf("Expected null, but got %s", result)
+		}
+	}
+
+	if result := arr.Int(3); result != -16 {
+		t.Errorf("Expected -16, but got %d", result)
+	}
+
+	bJson, err := New().ParseWith([]byte(`{big: 0xFFFFFFFFFFFFFFFF, half: .5, whole: 5.}`), ParseOptions{Relaxed: true, PreserveNumbers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := bJson.ToString(); result != `{"big":18446744073709551615,"half":0.5,"whole":5.0}` {
+		t.Errorf("Expected JSON number forms, but got %s", result)
+	}
+
+	cJson, err := New().ParseRelaxed(`{a: Infinity, b: -Infinity, c: 1, d: NaN}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := cJson.ToString(); result != `{"a":null,"b":null,"c":1,"d":null}` {
+		t.Errorf("Expected non-finite numbers as null, but got %s", result)
+	}
+
+	var pe *ParseError
+	hex := "-0x1" + strings.Repeat("0", 300)
+	if _, err := New().ParseRelaxed(hex); !errors.As(err, &pe) || pe.Msg != "number "+hex+" out of range" || pe.Offset != 0 {
+		t.Errorf("Expected ParseError for a hexadecimal number out of range, but got %v", err)
+	}
+}
+
+func TestParseRelaxedErrors(t *testing.T) {
+	var pe *ParseError
+
+	if _, err := New().ParseRelaxed(`{a: 1 /* open`); !errors.As(err, &pe) || pe.Msg != "unterminated comment" {
+		t.Errorf("Expected unterminated comment, but got %v", err)
+	}
+
+	if _, err := New().ParseRelaxed(`{a: 1} extra`); err == nil {
+		t.Errorf("Expected trailing content to fail")
+	}
+
+	if _, err := New().ParseRelaxed(`[1,,2]`); err == nil {
+		t.Errorf("Expected an empty element to fail")
+	}
+
+	if _, err := New().ParseRelaxed(`{1a: true}`); err == nil {
+		t.Errorf("Expected a key starting with a digit to fail")
+	}
+
+	if _, err := New().ParseStrict(`{"a": 1,}`); err == nil {
+		t.Errorf("Expected strict parse to reject trailing commas")
+	}
+
+	if _, err := New().ParseE(`{"a": 1, // note
+	"b": 2}`); err == nil {
+		t.Errorf("Expected default parse to reject comments")
+	}
+}
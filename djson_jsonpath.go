@@ -0,0 +1,1339 @@
+package djson
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Query and QueryNodes evaluate RFC 9535 JSONPath expressions such as
+// $.items[?@.price > 10].name, $..id or $.list[-2:]. Results are in
+// document order and share objects and arrays with m, as Object() does.
+// The functions length, count, match, search and value are supported.
+
+// QueryNode is a node selected by QueryNodes together with its normalized
+// path, e.g. $['items'][0]['name'].
+
+type QueryNode struct {
+	Path  string
+	Value *JSON
+}
+
+func (m *JSON) Query(expr string) ([]*JSON, error) {
+	nodes, err := m.QueryNodes(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*JSON, len(nodes))
+	for idx := range nodes {
+		result[idx] = nodes[idx].Value
+	}
+
+	return result, nil
+}
+
+func (m *JSON) QueryNodes(expr string) ([]QueryNode, error) {
+	q, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	switch m._Type {
+	case OBJECT:
+		root = m._Object
+	case ARRAY:
+		root = m._Array
+	default:
+		root = m.Interface()
+	}
+
+	found := q.eval(root, jpNode{value: root})
+
+	result := make([]QueryNode, len(found))
+	for idx, node := range found {
+		result[idx].Path = node.normalizedPath()
+
+		if len(node.path) == 0 {
+			result[idx].Value = m
+			continue
+		}
+
+		v, _ := normalizeValue(node.value)
+		if result[idx].Value, _ = wrapElement(v); result[idx].Value == nil {
+			result[idx].Value = New()
+		}
+	}
+
+	return result, nil
+}
+
+type jpNode struct {
+	value interface{}
+	path  []interface{}
+}
+
+func (n jpNode) child(key interface{}, v interface{}) jpNode {
+	path := make([]interface{}, len(n.path)+1)
+	copy(path, n.path)
+	path[len(n.path)] = key
+
+	return jpNode{value: v, path: path}
+}
+
+func (n jpNode) normalizedPath() string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+
+	for _, each := range n.path {
+		switch t := each.(type) {
+		case int:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(t))
+			sb.WriteByte(']')
+		case string:
+			sb.WriteString("['")
+			for _, r := range t {
+				switch r {
+				case '\b':
+					sb.WriteString(`\b`)
+				case '\f':
+					sb.WriteString(`\f`)
+				case '\n':
+					sb.WriteString(`\n`)
+				case '\r':
+					sb.WriteString(`\r`)
+				case '\t':
+					sb.WriteString(`\t`)
+				case '\'':
+					sb.WriteString(`\'`)
+				case '\\':
+					sb.WriteString(`\\`)
+				default:
+					if r < 0x20 {
+						sb.WriteString(`\u00`)
+						sb.WriteByte(hexDigits[r>>4])
+						sb.WriteByte(hexDigits[r&0xf])
+					} else {
+						sb.WriteRune(r)
+					}
+				}
+			}
+			sb.WriteString("']")
+		}
+	}
+
+	return sb.String()
+}
+
+// eachChild calls fn for the members of an object or the elements of an
+// array, in order.
+
+func (n jpNode) eachChild(fn func(child jpNode)) {
+	v, _ := normalizeValue(n.value)
+
+	switch t := v.(type) {
+	case *DO:
+		for _, key := range t.Keys() {
+			fn(n.child(key, t.Map[key]))
+		}
+	case *DA:
+		for idx := range t.Element {
+			fn(n.child(idx, t.Element[idx]))
+		}
+	}
+}
+
+type jpQuery struct {
+	relative bool
+	segments []jpSegment
+}
+
+// singular reports whether the query selects at most one node, i.e. uses
+// only name and index selectors.
+
+func (q *jpQuery) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if kind := seg.selectors[0].kind; kind != jpName && kind != jpIndex {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *jpQuery) eval(root interface{}, cur jpNode) []jpNode {
+	nodes := []jpNode{cur}
+	if !q.relative {
+		nodes[0] = jpNode{value: root}
+	}
+
+	for idx := range q.segments {
+		var next []jpNode
+		for _, node := range nodes {
+			next = q.segments[idx].apply(root, node, next)
+		}
+		nodes = next
+	}
+
+	return nodes
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+func (s *jpSegment) apply(root interface{}, node jpNode, out []jpNode) []jpNode {
+	for idx := range s.selectors {
+		out = s.selectors[idx].apply(root, node, out)
+	}
+
+	if s.descendant {
+		node.eachChild(func(child jpNode) {
+			out = s.apply(root, child, out)
+		})
+	}
+
+	return out
+}
+
+const (
+	jpName = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSelector struct {
+	kind     int
+	name     string
+	index    int
+	end      int
+	step     int
+	hasStart bool
+	hasEnd   bool
+	filter   jpExpr
+}
+
+func (s *jpSelector) apply(root interface{}, node jpNode, out []jpNode) []jpNode {
+	v, _ := normalizeValue(node.value)
+
+	switch s.kind {
+	case jpName:
+		if obj, ok := v.(*DO); ok {
+			if each, ok := obj.Map[s.name]; ok {
+				out = append(out, node.child(s.name, each))
+			}
+		}
+	case jpWildcard:
+		node.eachChild(func(child jpNode) {
+			out = append(out, child)
+		})
+	case jpIndex:
+		if arr, ok := v.(*DA); ok {
+			idx := s.index
+			if idx < 0 {
+				idx += len(arr.Element)
+			}
+			if idx >= 0 && idx < len(arr.Element) {
+				out = append(out, node.child(idx, arr.Element[idx]))
+			}
+		}
+	case jpSlice:
+		if arr, ok := v.(*DA); ok {
+			out = s.applySlice(arr, node, out)
+		}
+	case jpFilter:
+		node.eachChild(func(child jpNode) {
+			if jpTruth(s.filter, s.filter.eval(root, child)) {
+				out = append(out, child)
+			}
+		})
+	}
+
+	return out
+}
+
+// applySlice follows RFC 9535, section 2.3.4.2.
+
+func (s *jpSelector) applySlice(arr *DA, node jpNode, out []jpNode) []jpNode {
+	n := len(arr.Element)
+	if s.step == 0 {
+		return out
+	}
+
+	norm := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := 0, n
+		if s.hasStart {
+			start = norm(s.index)
+		}
+		if s.hasEnd {
+			end = norm(s.end)
+		}
+
+		for idx := clamp(start, 0, n); idx < clamp(end, 0, n); idx += s.step {
+			out = append(out, node.child(idx, arr.Element[idx]))
+		}
+		return out
+	}
+
+	start, end := n-1, -n-1
+	if s.hasStart {
+		start = norm(s.index)
+	}
+	if s.hasEnd {
+		end = norm(s.end)
+	}
+
+	for idx := clamp(start, -1, n-1); clamp(end, -1, n-1) < idx; idx += s.step {
+		out = append(out, node.child(idx, arr.Element[idx]))
+	}
+	return out
+}
+
+// Filter expressions have one of three types (RFC 9535, section 2.4.1).
+
+const (
+	jpValueType = iota
+	jpLogicalType
+	jpNodesType
+)
+
+type jpResult struct {
+	value   interface{}
+	nothing bool
+	logical bool
+	nodes   []jpNode
+}
+
+type jpExpr interface {
+	kind() int
+	eval(root interface{}, cur jpNode) jpResult
+}
+
+func jpTruth(e jpExpr, r jpResult) bool {
+	if e.kind() == jpNodesType {
+		return len(r.nodes) > 0
+	}
+	return r.logical
+}
+
+func jpValue(e jpExpr, r jpResult) (interface{}, bool) {
+	if e.kind() == jpNodesType {
+		if len(r.nodes) != 1 {
+			return nil, false
+		}
+		return r.nodes[0].value, true
+	}
+	return r.value, !r.nothing
+}
+
+type jpLiteral struct {
+	value interface{}
+}
+
+func (e *jpLiteral) kind() int {
+	return jpValueType
+}
+
+func (e *jpLiteral) eval(root interface{}, cur jpNode) jpResult {
+	return jpResult{value: e.value}
+}
+
+type jpQueryExpr struct {
+	query *jpQuery
+}
+
+func (e *jpQueryExpr) kind() int {
+	return jpNodesType
+}
+
+func (e *jpQueryExpr) eval(root interface{}, cur jpNode) jpResult {
+	return jpResult{nodes: e.query.eval(root, cur)}
+}
+
+type jpNot struct {
+	expr jpExpr
+}
+
+func (e *jpNot) kind() int {
+	return jpLogicalType
+}
+
+func (e *jpNot) eval(root interface{}, cur jpNode) jpResult {
+	return jpResult{logical: !jpTruth(e.expr, e.expr.eval(root, cur))}
+}
+
+type jpParen struct {
+	expr jpExpr
+}
+
+func (e *jpParen) kind() int {
+	return jpLogicalType
+}
+
+func (e *jpParen) eval(root interface{}, cur jpNode) jpResult {
+	return jpResult{logical: jpTruth(e.expr, e.expr.eval(root, cur))}
+}
+
+type jpLogical struct {
+	and         bool
+	left, right jpExpr
+}
+
+func (e *jpLogical) kind() int {
+	return jpLogicalType
+}
+
+func (e *jpLogical) eval(root interface{}, cur jpNode) jpResult {
+	left := jpTruth(e.left, e.left.eval(root, cur))
+
+	if e.and && !left || !e.and && left {
+		return jpResult{logical: left}
+	}
+
+	return jpResult{logical: jpTruth(e.right, e.right.eval(root, cur))}
+}
+
+type jpCompare struct {
+	op          string
+	left, right jpExpr
+}
+
+func (e *jpCompare) kind() int {
+	return jpLogicalType
+}
+
+func (e *jpCompare) eval(root interface{}, cur jpNode) jpResult {
+	a, aok := jpValue(e.left, e.left.eval(root, cur))
+	b, bok := jpValue(e.right, e.right.eval(root, cur))
+
+	equal := func() bool {
+		if !aok || !bok {
+			return !aok && !bok
+		}
+		return jpEqual(a, b)
+	}
+
+	less := func(x, y interface{}) bool {
+		return aok && bok && jpLess(x, y)
+	}
+
+	var r bool
+	switch e.op {
+	case "==":
+		r = equal()
+	case "!=":
+		r = !equal()
+	case "<":
+		r = less(a, b)
+	case "<=":
+		r = less(a, b) || equal()
+	case ">":
+		r = less(b, a)
+	case ">=":
+		r = less(b, a) || equal()
+	}
+
+	return jpResult{logical: r}
+}
+
+type jpNum struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+func jpNumber(v interface{}) (jpNum, bool) {
+	switch t := v.(type) {
+	case int, int8, int16, int32, int64:
+		i, _ := getIntBase(t)
+		return jpNum{i: i, f: float64(i), isInt: true}, true
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := getUint64Base(t)
+		if u <= math.MaxInt64 {
+			return jpNum{i: int64(u), f: float64(u), isInt: true}, true
+		}
+		return jpNum{f: float64(u)}, true
+	case float32, float64:
+		f, _ := getFloatBase(t)
+		return jpNum{f: f}, true
+	case Number:
+		if i, err := t.Int64(); err == nil && t.IsInt() {
+			return jpNum{i: i, f: float64(i), isInt: true}, true
+		}
+		if f, err := t.Float64(); err == nil {
+			return jpNum{f: f}, true
+		}
+	}
+
+	return jpNum{}, false
+}
+
+func (a jpNum) compare(b jpNum) int {
+	if a.isInt && b.isInt {
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	}
+
+	switch {
+	case a.f < b.f:
+		return -1
+	case a.f > b.f:
+		return 1
+	}
+	return 0
+}
+
+func jpEqual(a, b interface{}) bool {
+	a, _ = normalizeValue(a)
+	b, _ = normalizeValue(b)
+
+	if na, ok := jpNumber(a); ok {
+		nb, ok := jpNumber(b)
+		return ok && na.compare(nb) == 0
+	}
+
+	switch ta := a.(type) {
+	case nil:
+		return b == nil
+	case string:
+		tb, ok := b.(string)
+		return ok && ta == tb
+	case bool:
+		tb, ok := b.(bool)
+		return ok && ta == tb
+	case *DA:
+		tb, ok := b.(*DA)
+		if !ok || len(ta.Element) != len(tb.Element) {
+			return false
+		}
+		for idx := range ta.Element {
+			if !jpEqual(ta.Element[idx], tb.Element[idx]) {
+				return false
+			}
+		}
+		return true
+	case *DO:
+		tb, ok := b.(*DO)
+		if !ok || len(ta.Map) != len(tb.Map) {
+			return false
+		}
+		for key, each := range ta.Map {
+			other, ok := tb.Map[key]
+			if !ok || !jpEqual(each, other) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func jpLess(a, b interface{}) bool {
+	if na, ok := jpNumber(a); ok {
+		nb, ok := jpNumber(b)
+		return ok && na.compare(nb) < 0
+	}
+
+	sa, ok := a.(string)
+	if !ok {
+		return false
+	}
+
+	sb, ok := b.(string)
+	return ok && sa < sb
+}
+
+type jpFunction struct {
+	params []int
+	result int
+}
+
+var jpFunctions = map[string]jpFunction{
+	"length": {[]int{jpValueType}, jpValueType},
+	"count":  {[]int{jpNodesType}, jpValueType},
+	"match":  {[]int{jpValueType, jpValueType}, jpLogicalType},
+	"search": {[]int{jpValueType, jpValueType}, jpLogicalType},
+	"value":  {[]int{jpNodesType}, jpValueType},
+}
+
+type jpCall struct {
+	name    string
+	args    []jpExpr
+	result  int
+	regexps sync.Map
+}
+
+func (e *jpCall) kind() int {
+	return e.result
+}
+
+func (e *jpCall) eval(root interface{}, cur jpNode) jpResult {
+	switch e.name {
+	case "length":
+		v, ok := jpValue(e.args[0], e.args[0].eval(root, cur))
+		if !ok {
+			return jpResult{nothing: true}
+		}
+
+		v, _ = normalizeValue(v)
+		switch t := v.(type) {
+		case string:
+			return jpResult{value: int64(utf8.RuneCountInString(t))}
+		case *DA:
+			return jpResult{value: int64(len(t.Element))}
+		case *DO:
+			return jpResult{value: int64(len(t.Map))}
+		}
+		return jpResult{nothing: true}
+	case "count":
+		return jpResult{value: int64(len(e.args[0].eval(root, cur).nodes))}
+	case "value":
+		nodes := e.args[0].eval(root, cur).nodes
+		if len(nodes) != 1 {
+			return jpResult{nothing: true}
+		}
+		return jpResult{value: nodes[0].value}
+	}
+
+	// match and search
+	v, vok := jpValue(e.args[0], e.args[0].eval(root, cur))
+	p, pok := jpValue(e.args[1], e.args[1].eval(root, cur))
+
+	s, sok := v.(string)
+	pattern, patternOk := p.(string)
+	if !vok || !pok || !sok || !patternOk {
+		return jpResult{}
+	}
+
+	re := e.regexp(pattern)
+	return jpResult{logical: re != nil && re.MatchString(s)}
+}
+
+// regexp compiles an I-Regexp (RFC 9485). Its "." does not match line
+// terminators, and match has to match the whole string. Invalid patterns
+// give nil, so that the function is false.
+
+func (e *jpCall) regexp(pattern string) *regexp.Regexp {
+	if re, ok := e.regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	var sb strings.Builder
+	inClass := false
+
+	for idx := 0; idx < len(pattern); idx++ {
+		switch c := pattern[idx]; {
+		case c == '\\' && idx+1 < len(pattern):
+			sb.WriteByte(c)
+			idx++
+			c = pattern[idx]
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			sb.WriteString(`[^\n\r]`)
+			continue
+		}
+		sb.WriteByte(pattern[idx])
+	}
+
+	expr := sb.String()
+	if e.name == "match" {
+		expr = `\A(?:` + expr + `)\z`
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		re = nil
+	}
+
+	e.regexps.Store(pattern, re)
+	return re
+}
+
+type jpParser struct {
+	s   string
+	pos int
+}
+
+func parseJSONPath(expr string) (*jpQuery, error) {
+	p := &jpParser{s: expr}
+
+	if p.peek() != '$' {
+		return nil, p.fail("JSONPath query must start with $")
+	}
+	p.pos++
+
+	q, err := p.parseSegments(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.s) {
+		return nil, p.fail("unexpected character in JSONPath query")
+	}
+
+	return q, nil
+}
+
+func (p *jpParser) fail(msg string) error {
+	return newParseError([]byte(p.s), int64(p.pos), msg)
+}
+
+func (p *jpParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *jpParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func isNameFirst(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r >= 0x80
+}
+
+func (p *jpParser) parseSegments(relative bool) (*jpQuery, error) {
+	q := &jpQuery{relative: relative}
+
+	for {
+		save := p.pos
+		p.skipSpace()
+
+		var seg jpSegment
+		var err error
+
+		switch {
+		case p.peek() == '[':
+			seg.selectors, err = p.parseBracketed()
+		case strings.HasPrefix(p.s[p.pos:], ".."):
+			p.pos += 2
+			seg.descendant = true
+			if p.peek() == '[' {
+				seg.selectors, err = p.parseBracketed()
+			} else {
+				seg.selectors, err = p.parseDotSelector()
+			}
+		case p.peek() == '.':
+			p.pos++
+			seg.selectors, err = p.parseDotSelector()
+		default:
+			p.pos = save
+			return q, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		q.segments = append(q.segments, seg)
+	}
+}
+
+func (p *jpParser) parseDotSelector() ([]jpSelector, error) {
+	if p.peek() == '*' {
+		p.pos++
+		return []jpSelector{{kind: jpWildcard}}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		if !isNameFirst(r) && !(p.pos > start && r >= '0' && r <= '9') {
+			break
+		}
+		p.pos += size
+	}
+
+	if p.pos == start {
+		return nil, p.fail("expected member name or *")
+	}
+
+	return []jpSelector{{kind: jpName, name: p.s[start:p.pos]}}, nil
+}
+
+func (p *jpParser) parseBracketed() ([]jpSelector, error) {
+	p.pos++ // '['
+
+	var selectors []jpSelector
+
+	for {
+		p.skipSpace()
+
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return selectors, nil
+		default:
+			return nil, p.fail("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *jpParser) parseSelector() (jpSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		return jpSelector{kind: jpName, name: name}, err
+	case c == '*':
+		p.pos++
+		return jpSelector{kind: jpWildcard}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+
+		start := p.pos
+		expr, err := p.parseOr()
+		if err != nil {
+			return jpSelector{}, err
+		}
+		if !jpTestable(expr) {
+			p.pos = start
+			return jpSelector{}, p.fail("filter must be a logical expression")
+		}
+
+		return jpSelector{kind: jpFilter, filter: expr}, nil
+	}
+
+	sel := jpSelector{kind: jpIndex, step: 1}
+
+	if p.peek() != ':' {
+		idx, err := p.parseInt()
+		if err != nil {
+			return sel, err
+		}
+		sel.index, sel.hasStart = idx, true
+	}
+
+	save := p.pos
+	p.skipSpace()
+	if p.peek() != ':' {
+		if !sel.hasStart {
+			return sel, p.fail("expected selector")
+		}
+		p.pos = save
+		return sel, nil
+	}
+
+	sel.kind = jpSlice
+	p.pos++
+	p.skipSpace()
+
+	if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+		end, err := p.parseInt()
+		if err != nil {
+			return sel, err
+		}
+		sel.end, sel.hasEnd = end, true
+		p.skipSpace()
+	}
+
+	if p.peek() == ':' {
+		p.pos++
+		p.skipSpace()
+
+		if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+			step, err := p.parseInt()
+			if err != nil {
+				return sel, err
+			}
+			sel.step = step
+		}
+	}
+
+	return sel, nil
+}
+
+// parseInt reads an integer within the I-JSON range, without leading
+// zeros or "-0".
+
+func (p *jpParser) parseInt() (int, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+
+	text := p.s[start:p.pos]
+	if p.pos == digits || p.s[digits] == '0' && (p.pos-digits > 1 || digits > start) {
+		p.pos = start
+		return 0, p.fail("invalid integer")
+	}
+
+	i, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || i > 1<<53-1 || i < -(1<<53-1) {
+		p.pos = start
+		return 0, p.fail("integer out of range")
+	}
+
+	return int(i), nil
+}
+
+func (p *jpParser) parseString() (string, error) {
+	start := p.pos
+	quote := p.s[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+
+	for {
+		if p.pos >= len(p.s) {
+			p.pos = start
+			return "", p.fail("unterminated string")
+		}
+
+		c := p.s[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c < 0x20:
+			return "", p.fail("control character in string")
+		case c != '\\':
+			sb.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		switch e := p.peek(); e {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '/', '\\':
+			sb.WriteByte(e)
+		case '\'', '"':
+			if e != quote {
+				return "", p.fail("invalid escape sequence")
+			}
+			sb.WriteByte(e)
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteRune(r)
+			continue
+		default:
+			return "", p.fail("invalid escape sequence")
+		}
+		p.pos++
+	}
+}
+
+func (p *jpParser) parseUnicodeEscape() (rune, error) {
+	hex := func() (rune, bool) {
+		if p.pos+5 > len(p.s) {
+			return 0, false
+		}
+		v, err := strconv.ParseUint(p.s[p.pos+1:p.pos+5], 16, 32)
+		if err != nil {
+			return 0, false
+		}
+		p.pos += 5
+		return rune(v), true
+	}
+
+	r, ok := hex()
+	if !ok {
+		return 0, p.fail("invalid \\u escape")
+	}
+
+	if utf16.IsSurrogate(r) {
+		if r >= 0xdc00 || !strings.HasPrefix(p.s[p.pos:], `\u`) {
+			return 0, p.fail("invalid surrogate pair")
+		}
+
+		p.pos++
+		low, ok := hex()
+		if !ok || low < 0xdc00 || low > 0xdfff {
+			return 0, p.fail("invalid surrogate pair")
+		}
+		r = utf16.DecodeRune(r, low)
+	}
+
+	return r, nil
+}
+
+func jpTestable(e jpExpr) bool {
+	return e.kind() != jpValueType
+}
+
+func jpComparable(e jpExpr) bool {
+	if q, ok := e.(*jpQueryExpr); ok {
+		return q.query.singular()
+	}
+	return e.kind() == jpValueType
+}
+
+func (p *jpParser) parseOr() (jpExpr, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *jpParser) parseAnd() (jpExpr, error) {
+	return p.parseLogical("&&", p.parseBasic)
+}
+
+func (p *jpParser) parseLogical(op string, operand func() (jpExpr, error)) (jpExpr, error) {
+	start := p.pos
+
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		save := p.pos
+		p.skipSpace()
+
+		if !strings.HasPrefix(p.s[p.pos:], op) {
+			p.pos = save
+			return left, nil
+		}
+
+		if !jpTestable(left) {
+			p.pos = start
+			return nil, p.fail("operand of " + op + " must be a logical expression")
+		}
+
+		p.pos += len(op)
+		p.skipSpace()
+
+		rightStart := p.pos
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if !jpTestable(right) {
+			p.pos = rightStart
+			return nil, p.fail("operand of " + op + " must be a logical expression")
+		}
+
+		left = &jpLogical{and: op == "&&", left: left, right: right}
+	}
+}
+
+func (p *jpParser) parseBasic() (jpExpr, error) {
+	start := p.pos
+
+	if p.peek() == '!' {
+		p.pos++
+		p.skipSpace()
+
+		var expr jpExpr
+		var err error
+
+		if p.peek() == '(' {
+			expr, err = p.parseParen()
+		} else {
+			expr, err = p.parsePrimary()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !jpTestable(expr) {
+			p.pos = start
+			return nil, p.fail("operand of ! must be a logical expression")
+		}
+
+		return &jpNot{expr: expr}, nil
+	}
+
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	save := p.pos
+	p.skipSpace()
+
+	op := ""
+	for _, each := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.s[p.pos:], each) {
+			op = each
+			break
+		}
+	}
+
+	if op == "" {
+		p.pos = save
+		return left, nil
+	}
+
+	if !jpComparable(left) {
+		p.pos = start
+		return nil, p.fail("comparison needs a literal, singular query or value function")
+	}
+
+	p.pos += len(op)
+	p.skipSpace()
+
+	rightStart := p.pos
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if !jpComparable(right) {
+		p.pos = rightStart
+		return nil, p.fail("comparison needs a literal, singular query or value function")
+	}
+
+	return &jpCompare{op: op, left: left, right: right}, nil
+}
+
+func (p *jpParser) parseParen() (jpExpr, error) {
+	p.pos++ // '('
+	p.skipSpace()
+
+	start := p.pos
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !jpTestable(expr) {
+		p.pos = start
+		return nil, p.fail("expected a logical expression")
+	}
+
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.fail("expected ')'")
+	}
+	p.pos++
+
+	return &jpParen{expr: expr}, nil
+}
+
+func (p *jpParser) parsePrimary() (jpExpr, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		q, err := p.parseSegments(c == '@')
+		if err != nil {
+			return nil, err
+		}
+		return &jpQueryExpr{query: q}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &jpLiteral{value: s}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for c := p.peek(); c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.s[start:p.pos]
+
+		if p.peek() == '(' {
+			return p.parseCall(name, start)
+		}
+
+		switch name {
+		case "true":
+			return &jpLiteral{value: true}, nil
+		case "false":
+			return &jpLiteral{value: false}, nil
+		case "null":
+			return &jpLiteral{value: nil}, nil
+		}
+
+		p.pos = start
+		return nil, p.fail("unknown literal " + strconv.Quote(name))
+	}
+
+	return nil, p.fail("expected an expression")
+}
+
+func (p *jpParser) parseNumber() (jpExpr, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+
+	if p.pos == digits || p.s[digits] == '0' && p.pos-digits > 1 {
+		p.pos = start
+		return nil, p.fail("invalid number")
+	}
+
+	isFloat := false
+
+	if p.peek() == '.' {
+		p.pos++
+		frac := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == frac {
+			return nil, p.fail("invalid number")
+		}
+		isFloat = true
+	}
+
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		exp := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == exp {
+			return nil, p.fail("invalid number")
+		}
+		isFloat = true
+	}
+
+	text := p.s[start:p.pos]
+
+	if !isFloat {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &jpLiteral{value: i}, nil
+		}
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.fail("invalid number")
+	}
+
+	return &jpLiteral{value: f}, nil
+}
+
+func (p *jpParser) parseCall(name string, start int) (jpExpr, error) {
+	fn, ok := jpFunctions[name]
+	if !ok {
+		p.pos = start
+		return nil, p.fail("unknown function " + strconv.Quote(name))
+	}
+
+	p.pos++ // '('
+	p.skipSpace()
+
+	call := &jpCall{name: name, result: fn.result}
+
+	for p.peek() != ')' {
+		argStart := p.pos
+
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		idx := len(call.args)
+		if idx >= len(fn.params) {
+			p.pos = argStart
+			return nil, p.fail("too many arguments for " + name)
+		}
+
+		switch fn.params[idx] {
+		case jpValueType:
+			ok = jpComparable(arg)
+		case jpNodesType:
+			_, isQuery := arg.(*jpQueryExpr)
+			ok = isQuery || arg.kind() == jpNodesType
+		case jpLogicalType:
+			ok = jpTestable(arg)
+		}
+
+		if !ok {
+			p.pos = argStart
+			return nil, p.fail("wrong argument type for " + name)
+		}
+
+		call.args = append(call.args, arg)
+
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+			p.skipSpace()
+		} else if p.peek() != ')' {
+			return nil, p.fail("expected ',' or ')'")
+		}
+	}
+	p.pos++
+
+	if len(call.args) != len(fn.params) {
+		p.pos = start
+		return nil, p.fail("wrong number of arguments for " + name)
+	}
+
+	return call, nil
+}
@@ -0,0 +1,193 @@
+package djson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const jsonPathStore = `{"store": {
+	"book": [
+		{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+		{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+		{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+		{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+	],
+	"bicycle": {"color": "red", "price": 399}
+}}`
+
+func jsonPathResult(t *testing.T, aJson *JSON, expr string) string {
+	nodes, err := aJson.Query(expr)
+	if err != nil {
+		t.Errorf("Expected result, but got %v for %s", err, expr)
+		return ""
+	}
+
+	parts := make([]string, len(nodes))
+	for idx := range nodes {
+		parts[idx] = nodes[idx].ToStringWith(EncodeOptions{})
+	}
+
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func TestQuery(t *testing.T) {
+	aJson, err := New().ParseWith([]byte(jsonPathStore), ParseOptions{Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{`$.store.book[*].author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$..author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$.store.*.color`, `["red"]`},
+		{`$.store..price`, `[8.95,12.99,8.99,22.99,399]`},
+		{`$..book[2].title`, `["Moby Dick"]`},
+		{`$..book[-1].title`, `["The Lord of the Rings"]`},
+		{`$..book[0,1].title`, `["Sayings of the Century","Sword of Honour"]`},
+		{`$..book[:2].title`, `["Sayings of the Century","Sword of Honour"]`},
+		{`$..book[?@.isbn].title`, `["Moby Dick","The Lord of the Rings"]`},
+		{`$..book[?@.price<10].title`, `["Sayings of the Century","Moby Dick"]`},
+		{`$.store.book[?@.price > 10 && @.category == 'fiction'].author`, `["Evelyn Waugh","J. R. R. Tolkien"]`},
+		{`$.store.book[?!(@.price < 10 || @.isbn)].title`, `["Sword of Honour"]`},
+		{`$.store["bicycle"]['color']`, `["red"]`},
+		{`$.store.book[?@.price == $.store.book[0].price].title`, `["Sayings of the Century"]`},
+		{`$.store.book[?length(@.title) > 15].title`, `["Sayings of the Century","The Lord of the Rings"]`},
+		{`$.store[?count(@.*) == 2].color`, `["red"]`},
+		{`$.store.book[?match(@.author, 'J.*')].title`, `["The Lord of the Rings"]`},
+		{`$.store.book[?search(@.author, 'Mel')].price`, `[8.99]`},
+		{`$.store.book[?value(@..isbn) == '0-553-21311-3'].title`, `["Moby Dick"]`},
+		{`$.missing`, `[]`},
+		{`$`, `[` + aJson.ToStringWith(EncodeOptions{}) + `]`},
+	}
+
+	for _, each := range cases {
+		if result := jsonPathResult(t, aJson, each.expr); result != each.expected {
+			t.Errorf("Expected %s, but got %s for %s", each.expected, result, each.expr)
+		}
+	}
+}
+
+func TestQuerySelectors(t *testing.T) {
+	aJson := New().Parse(`{"a": ["a", "b", "c", "d", "e", "f", "g"], "o": {"j": 1, "k": 2},
+		"n": [1, 1.0, "1", true, null, [1], {"x": 1}], "e": {"": 1, "'": 2, "☺": 3}}`)
+
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{`$.a[1:3]`, `["b","c"]`},
+		{`$.a[5:]`, `["f","g"]`},
+		{`$.a[1:5:2]`, `["b","d"]`},
+		{`$.a[5:1:-2]`, `["f","d"]`},
+		{`$.a[::-1]`, `["g","f","e","d","c","b","a"]`},
+		{`$.a[-2:]`, `["f","g"]`},
+		{`$.a[0:10:0]`, `[]`},
+		{`$.a[7]`, `[]`},
+		{`$.a[0, 3, 0]`, `["a","d","a"]`},
+		{`$.a[:1, -1]`, `["a","g"]`},
+		{`$.o[?@ > 1]`, `[2]`},
+		{`$.n[?@ == 1]`, `[1,1]`},
+		{`$.n[?@ == $.n[5]]`, `[[1]]`},
+		{`$.n[?@ == null]`, `[null]`},
+		{`$.n[?@.x == 1]`, `[{"x":1}]`},
+		{`$.n[?@.x == @.y]`, `[1,1,"1",true,null,[1]]`},
+		{`$.e['']`, `[1]`},
+		{`$.e["'"]`, `[2]`},
+		{`$.e['☺']`, `[3]`},
+		{`$.e.` + "☺", `[3]`},
+		{`$.o[?length(@) == 1]`, `[]`},
+		{`$[?length(@) == 7]`, `[["a","b","c","d","e","f","g"],[1,1,"1",true,null,[1],{"x":1}]]`},
+		{`$.a[?match(@, 'a|b')]`, `["a","b"]`},
+		{`$.a[?match(@, '[')]`, `[]`},
+		{`$.o[?@ == 1 || @ == 2]`, `[1,2]`},
+	}
+
+	for _, each := range cases {
+		if result := jsonPathResult(t, aJson, each.expr); result != each.expected {
+			t.Errorf("Expected %s, but got %s for %s", each.expected, result, each.expr)
+		}
+	}
+}
+
+func TestQueryNodes(t *testing.T) {
+	aJson := New().Parse(`{"a": [{"b": 1}, {"b": 2}], "c\n'd": 3}`)
+
+	nodes, err := aJson.QueryNodes(`$..b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`$['a'][0]['b']`, `$['a'][1]['b']`}
+	if len(nodes) != len(expected) {
+		t.Fatalf("Expected %d nodes, but got %d", len(expected), len(nodes))
+	}
+
+	for idx := range nodes {
+		if nodes[idx].Path != expected[idx] {
+			t.Errorf("Expected %s, but got %s", expected[idx], nodes[idx].Path)
+		}
+	}
+
+	nodes, err = aJson.QueryNodes(`$.*`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, node := range nodes {
+		if node.Value.IsInt() && node.Path != `$['c\n\'d']` {
+			t.Errorf("Expected $['c\\n\\'d'], but got %s", node.Path)
+		}
+	}
+
+	// objects and arrays are shared with the document
+	found, err := aJson.Query(`$.a[?@.b == 2]`)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("Expected one node, but got %d, %v", len(found), err)
+	}
+
+	found[0].Put("b", 20)
+	if result := aJson.IntPath(`["a"][1]["b"]`); result != 20 {
+		t.Errorf("Expected 20, but got %d", result)
+	}
+
+	root, err := aJson.Query(`$`)
+	if err != nil || len(root) != 1 || root[0] != aJson {
+		t.Errorf("Expected the document itself for $")
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	cases := []string{
+		``,
+		`a.b`,
+		`$.`,
+		`$[`,
+		`$['a'`,
+		`$[01]`,
+		`$[-0]`,
+		`$[9007199254740992]`,
+		`$[?@.a == 1 == 2]`,
+		`$[?1]`,
+		`$[?@ == [1]]`,
+		`$[?@.* == 1]`,
+		`$[?length(@) ]`,
+		`$[?length(@.*) == 1]`,
+		`$[?count(1) == 1]`,
+		`$[?foo(@)]`,
+		`$[?match(@.a)]`,
+		`$['\x']`,
+		`$.a `,
+		`$. a`,
+	}
+
+	for _, each := range cases {
+		var pErr *ParseError
+		if _, err := New().Parse(`{}`).Query(each); !errors.As(err, &pErr) {
+			t.Errorf("Expected ParseError, but got %v for %q", err, each)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package djson
+
+import (
+	"bytes"
+	"errors"
+)
+
+// JSON, DO and DA implement json.Marshaler / json.Unmarshaler and the
+// encoding.TextMarshaler pair, so they can be fields of ordinary structs.
+// The marshal methods have value receivers so that non-pointer fields are
+// encoded as well. Unmarshalling keeps the key order of the input and
+// reads numbers as Parse does, into INT and FLOAT, except that numbers
+// these cannot hold exactly stay Number, so a value round-trips unchanged.
+
+var unmarshalOptions = ParseOptions{Strict: true, Ordered: true, PreservePrecision: true}
+
+func (m JSON) MarshalJSON() ([]byte, error) {
+	return encodeWith(m, EncodeOptions{})
+}
+
+func (m *JSON) UnmarshalJSON(b []byte) error {
+	data, err := decodeDocument(b, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	*m = JSON{}
+	m.setDecoded(data)
+
+	return nil
+}
+
+func (m JSON) MarshalText() ([]byte, error) {
+	return m.MarshalJSON()
+}
+
+func (m *JSON) UnmarshalText(b []byte) error {
+	return m.UnmarshalJSON(b)
+}
+
+func (m DO) MarshalJSON() ([]byte, error) {
+	return encodeWith(m, EncodeOptions{})
+}
+
+func (m *DO) UnmarshalJSON(b []byte) error {
+	if isNullDocument(b) {
+		return nil
+	}
+
+	data, err := decodeDocument(b, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := data.(*DO)
+	if !ok {
+		return errors.New("not Object")
+	}
+
+	*m = *obj
+	return nil
+}
+
+func (m DO) MarshalText() ([]byte, error) {
+	return m.MarshalJSON()
+}
+
+func (m *DO) UnmarshalText(b []byte) error {
+	return m.UnmarshalJSON(b)
+}
+
+func (m DA) MarshalJSON() ([]byte, error) {
+	return encodeWith(m, EncodeOptions{})
+}
+
+func (m *DA) UnmarshalJSON(b []byte) error {
+	if isNullDocument(b) {
+		return nil
+	}
+
+	data, err := decodeDocument(b, unmarshalOptions)
+	if err != nil {
+		return err
+	}
+
+	arr, ok := data.(*DA)
+	if !ok {
+		return errors.New("not Array")
+	}
+
+	*m = *arr
+	return nil
+}
+
+func (m DA) MarshalText() ([]byte, error) {
+	return m.MarshalJSON()
+}
+
+func (m *DA) UnmarshalText(b []byte) error {
+	return m.UnmarshalJSON(b)
+}
+
+// isNullDocument follows the encoding/json convention that null leaves a
+// non-pointer value unchanged.
+
+func isNullDocument(b []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(b), []byte("null"))
+}
@@ -0,0 +1,129 @@
+package djson
+
+import (
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type marshalEnvelope struct {
+	ID    int    `json:"id"`
+	Meta  *JSON  `json:"meta"`
+	Attrs DO     `json:"attrs"`
+	Tags  *DA    `json:"tags"`
+	Extra JSON   `json:"extra"`
+	Note  string `json:"note,omitempty"`
+}
+
+func TestMarshalJSON(t *testing.T) {
+	meta, _ := New().ParseWith([]byte(`{"z": 1, "a": {"big": 12345678901234567890123}}`), ParseOptions{Ordered: true, PreserveNumbers: true})
+	attrs := NewOrderedDO().Put("y", "<b>").Put("x", 1.5)
+
+	src := marshalEnvelope{
+		ID:    7,
+		Meta:  meta,
+		Attrs: *attrs,
+		Tags:  NewDA().Put("a").Put(2),
+		Extra: *NewString("text"),
+	}
+
+	expected := `{"id":7,"meta":{"z":1,"a":{"big":12345678901234567890123}},"attrs":{"y":"\u003cb\u003e","x":1.5},"tags":["a",2],"extra":"text"}`
+
+	b, err := stdjson.Marshal(src)
+	if err != nil || string(b) != expected {
+		t.Errorf("Expected %s, but got %s (%v)", expected, b, err)
+	}
+
+	b, err = json.Marshal(&src)
+	if err != nil || string(b) != expected {
+		t.Errorf("Expected %s, but got %s (%v)", expected, b, err)
+	}
+
+	var dst marshalEnvelope
+	if err := json.Unmarshal(b, &dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if b, _ := stdjson.Marshal(dst); string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+
+	dst = marshalEnvelope{}
+	if err := stdjson.Unmarshal([]byte(expected), &dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := dst.Meta.ToString(); result != `{"z":1,"a":{"big":12345678901234567890123}}` {
+		t.Errorf("Expected ordered meta, but got %s", result)
+	}
+
+	if result := dst.Attrs.String("y"); result != "<b>" || dst.Attrs.Keys()[0] != "y" {
+		t.Errorf("Expected <b> first, but got %s", result)
+	}
+
+	if dst.Tags.Len() != 2 || dst.Extra.String() != "text" {
+		t.Errorf("Expected 2 tags and text, but got %d %s", dst.Tags.Len(), dst.Extra.String())
+	}
+
+	var again marshalEnvelope
+	if err := json.Unmarshal([]byte(`{"meta": null, "attrs": null, "tags": [1], "extra": [true]}`), &again); err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Meta != nil || again.Attrs.Len() != 0 || again.Tags.Len() != 1 || again.Extra.Type() != "array" {
+		t.Errorf("Expected nil meta, empty attrs, [1] and an array, but got %v %v", again.Meta, again.Extra.ToString())
+	}
+
+	if err := stdjson.Unmarshal([]byte(`{"attrs": [1]}`), &again); err == nil {
+		t.Errorf("Expected an array into DO to fail")
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	keys := map[string]*JSON{"k": NewArray(1, "two")}
+
+	b, err := stdjson.Marshal(keys)
+	if err != nil || string(b) != `{"k":[1,"two"]}` {
+		t.Errorf("Expected {\"k\":[1,\"two\"]}, but got %s (%v)", b, err)
+	}
+
+	aJson := New()
+	if err := aJson.UnmarshalText([]byte(`{"a": 1}`)); err != nil || aJson.Int("a") != 1 {
+		t.Errorf("Expected 1, but got %v", err)
+	}
+
+	if b, _ := aJson.MarshalText(); string(b) != `{"a":1}` {
+		t.Errorf("Expected {\"a\":1}, but got %s", b)
+	}
+}
+
+func TestUnmarshalNumbers(t *testing.T) {
+	aJson := New()
+	doc := `{"i": 1, "f": 1.5, "whole": 2.0, "big": 12345678901234567890123, "pi": 3.14159265358979323846}`
+	if err := stdjson.Unmarshal([]byte(doc), aJson); err != nil {
+		t.Fatal(err)
+	}
+
+	i, _ := aJson.Get("i")
+	if !i.IsInt() || i.Type() != "int" {
+		t.Errorf("Expected INT, but got %s", i.Type())
+	}
+
+	for _, key := range []string{"f", "whole"} {
+		if result := aJson.Type(key); result != "float" {
+			t.Errorf("Expected float for %s, but got %s", key, result)
+		}
+	}
+
+	for _, key := range []string{"big", "pi"} {
+		if result := aJson.Type(key); result != "number" {
+			t.Errorf("Expected number for %s, but got %s", key, result)
+		}
+	}
+
+	expected := `{"i":1,"f":1.5,"whole":2,"big":12345678901234567890123,"pi":3.14159265358979323846}`
+	if b, _ := stdjson.Marshal(aJson); string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+}
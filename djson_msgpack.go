@@ -0,0 +1,510 @@
+package djson
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+// MessagePack values map onto djson values as follows: maps with string
+// keys become objects, integers become INT (NUMBER beyond int64), floats
+// become FLOAT and strings, booleans and nil the matching scalars. FLOAT
+// values are always written as floats, so 1.0 does not come back as 1.
+// Timestamps (extension type -1) are decoded as RFC 3339 strings. Binary
+// data, other extension types, NaN and infinite floats and maps with
+// non-string keys have no JSON equivalent and fail with
+// ErrMsgPackUnsupported.
+
+var ErrMsgPackUnsupported = errors.New("MessagePack value has no JSON equivalent")
+
+// MsgPackOptions controls ToMsgPack.
+// Timestamps writes strings in RFC 3339 format as timestamp extensions.
+// Only UTC strings in the form ParseMsgPack produces are converted, e.g.
+// 2024-01-01T00:00:00.5Z, so they decode to the same text. Others, such as
+// ones with a zone offset, stay strings because the timestamp would drop it.
+
+type MsgPackOptions struct {
+	Timestamps bool
+}
+
+const msgpackMaxDepth = 10000
+
+func (m *JSON) ToMsgPack(opts ...MsgPackOptions) ([]byte, error) {
+	return encodeMsgPack(m, opts)
+}
+
+func (m *DO) ToMsgPack(opts ...MsgPackOptions) ([]byte, error) {
+	return encodeMsgPack(m, opts)
+}
+
+func (m *DA) ToMsgPack(opts ...MsgPackOptions) ([]byte, error) {
+	return encodeMsgPack(m, opts)
+}
+
+func (m *JSON) ParseMsgPack(data []byte) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	d := &msgpackDecoder{
+		data: data,
+	}
+
+	v, err := d.decodeValue(0)
+	if err != nil {
+		return m, err
+	}
+
+	if d.pos != len(d.data) {
+		return m, d.fail("trailing data")
+	}
+
+	return m.setDecoded(v), nil
+}
+
+func encodeMsgPack(v interface{}, opts []MsgPackOptions) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if len(opts) > 0 {
+		e.opts = opts[0]
+	}
+
+	if err := e.encodeValue(v); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+type msgpackEncoder struct {
+	buf  []byte
+	opts MsgPackOptions
+}
+
+func (e *msgpackEncoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(i))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(u))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+	}
+}
+
+// encodeHead writes a str, array or map header. fix is the fixstr /
+// fixarray / fixmap prefix and fixMax its largest length. code8 is the
+// 8-bit form (str only, zero otherwise) and code16 the 16-bit form, which
+// the 32-bit form follows.
+
+func (e *msgpackEncoder) encodeHead(n int, fix byte, fixMax int, code8, code16 byte) {
+	switch {
+	case n <= fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		e.buf = append(e.buf, code8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, code16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, code16+1), uint32(n))
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) error {
+	if !utf8.ValidString(s) {
+		return errors.New("invalid UTF-8 in string")
+	}
+
+	if e.opts.Timestamps {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil && t.UTC().Format(time.RFC3339Nano) == s {
+			e.encodeTimestamp(t)
+			return nil
+		}
+	}
+
+	e.encodeHead(len(s), 0xa0, 31, 0xd9, 0xda)
+	e.buf = append(e.buf, s...)
+	return nil
+}
+
+func (e *msgpackEncoder) encodeTimestamp(t time.Time) {
+	sec := t.Unix()
+	nsec := uint64(t.Nanosecond())
+
+	switch {
+	case sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd6, 0xff), uint32(sec))
+	case sec>>34 == 0:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd7, 0xff), nsec<<34|uint64(sec))
+	default:
+		e.buf = append(e.buf, 0xc7, 12, 0xff)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(nsec))
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(sec))
+	}
+}
+
+func (e *msgpackEncoder) encodeValue(v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if t {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case string:
+		return e.encodeString(t)
+	case int, int8, int16, int32, int64:
+		i, _ := getIntBase(t)
+		e.encodeInt(i)
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := getUint64Base(t)
+		e.encodeUint(u)
+	case float32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xca), math.Float32bits(t))
+	case float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(t))
+	case Number:
+		if i, err := t.Int64(); err == nil && t.IsInt() {
+			e.encodeInt(i)
+		} else if u, err := t.Uint64(); err == nil && t.IsInt() {
+			e.encodeUint(u)
+		} else if f, err := t.Float64(); err == nil {
+			e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(f))
+		} else {
+			return errors.New("invalid Number " + strconv.Quote(string(t)))
+		}
+	case *DO:
+		if t == nil {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeObject(t)
+	case DO:
+		return e.encodeObject(&t)
+	case *DA:
+		if t == nil {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeArray(t)
+	case DA:
+		return e.encodeArray(&t)
+	case *JSON:
+		if t == nil {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+		return e.encodeValue(t.Interface())
+	case JSON:
+		return e.encodeValue(t.Interface())
+	case map[string]interface{}:
+		return e.encodeObject(MapToObject(t))
+	case Object:
+		return e.encodeObject(MapToObject(t))
+	case []interface{}:
+		return e.encodeArray(SliceToArray(t))
+	case Array:
+		return e.encodeArray(SliceToArray(t))
+	case time.Time:
+		e.encodeTimestamp(t)
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		data, err := decodeDocument(b, ParseOptions{Strict: true, Ordered: true})
+		if err != nil {
+			return err
+		}
+
+		return e.encodeValue(data)
+	}
+
+	return nil
+}
+
+func (e *msgpackEncoder) encodeObject(obj *DO) error {
+	e.encodeHead(len(obj.Map), 0x80, 15, 0, 0xde)
+
+	for _, key := range obj.Keys() {
+		if !utf8.ValidString(key) {
+			return errors.New("invalid UTF-8 in string")
+		}
+
+		e.encodeHead(len(key), 0xa0, 31, 0xd9, 0xda)
+		e.buf = append(e.buf, key...)
+
+		if err := e.encodeValue(obj.Map[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *msgpackEncoder) encodeArray(arr *DA) error {
+	e.encodeHead(len(arr.Element), 0x90, 15, 0, 0xdc)
+
+	for idx := range arr.Element {
+		if err := e.encodeValue(arr.Element[idx]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) fail(msg string) error {
+	return fmt.Errorf("msgpack: %s at offset %d", msg, d.pos)
+}
+
+func (d *msgpackDecoder) unsupported(what string, offset int) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrMsgPackUnsupported, what, offset)
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, d.fail("unexpected end of input")
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// readUint reads a big-endian unsigned integer of size bytes.
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	for idx := range b {
+		n = n<<8 | uint64(b[idx])
+	}
+
+	return n, nil
+}
+
+func (d *msgpackDecoder) decodeValue(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, d.fail("maximum depth exceeded")
+	}
+
+	start := d.pos
+
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0xa0 && c <= 0xbf:
+		return d.decodeString(int(c & 0x1f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.decodeArray(int(c&0x0f), depth)
+	case c >= 0x80 && c <= 0x8f:
+		return d.decodeMap(int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return Number(strconv.FormatUint(u, 10)), nil
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign-extend
+		shift := uint(64 - 8*size)
+		return int64(u<<shift) >> shift, nil
+	case 0xca, 0xcb:
+		u, err := d.readUint(4 << (c - 0xca))
+		if err != nil {
+			return nil, err
+		}
+
+		f := math.Float64frombits(u)
+		if c == 0xca {
+			f = float64(math.Float32frombits(uint32(u)))
+		}
+
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, d.unsupported("non-finite float", start)
+		}
+		return f, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n), depth)
+	case 0xc4, 0xc5, 0xc6:
+		return nil, d.unsupported("binary data", start)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1<<(c-0xd4), start)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(int(n), start)
+	}
+
+	d.pos = start
+	return nil, d.fail("invalid format byte 0x" + strconv.FormatUint(uint64(c), 16))
+}
+
+func (d *msgpackDecoder) decodeString(n int) (string, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return "", err
+	}
+
+	if !utf8.Valid(b) {
+		return "", d.fail("invalid UTF-8 in string")
+	}
+
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) decodeExt(n int, start int) (interface{}, error) {
+	typ, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(typ[0]) != -1 {
+		return nil, d.unsupported("extension type "+strconv.Itoa(int(int8(typ[0]))), start)
+	}
+
+	var t time.Time
+	switch n {
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(b)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(b)
+		t = time.Unix(int64(u&(1<<34-1)), int64(u>>34))
+	case 12:
+		t = time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b)))
+	default:
+		d.pos = start
+		return nil, d.fail("invalid timestamp length")
+	}
+
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int, depth int) (*DA, error) {
+	arr := NewDA()
+
+	for idx := 0; idx < n; idx++ {
+		v, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr.Element = append(arr.Element, v)
+	}
+
+	return arr, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int, depth int) (*DO, error) {
+	obj := NewOrderedDO()
+
+	for idx := 0; idx < n; idx++ {
+		keyStart := d.pos
+
+		k, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			return nil, d.unsupported("non-string map key", keyStart)
+		}
+
+		v, err := d.decodeValue(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.set(key, v)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,119 @@
+package djson
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestMsgPackEncode(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{int64(0), "00"},
+		{int64(127), "7f"},
+		{int64(128), "cc80"},
+		{int64(65536), "ce00010000"},
+		{int64(-1), "ff"},
+		{int64(-32), "e0"},
+		{int64(-33), "d0df"},
+		{int64(-129), "d1ff7f"},
+		{uint64(18446744073709551615), "cfffffffffffffffff"},
+		{1.0, "cb3ff0000000000000"},
+		{float32(0.5), "ca3f000000"},
+		{Number("12345678901"), "cf00000002dfdc1c35"},
+		{"hi", "a26869"},
+		{nil, "c0"},
+		{Array{true, false}, "92c3c2"},
+	}
+
+	for _, each := range cases {
+		b, err := encodeMsgPack(each.value, nil)
+		if err != nil || hex.EncodeToString(b) != each.expected {
+			t.Errorf("Expected %s for %v, but got %x (%v)", each.expected, each.value, b, err)
+		}
+	}
+
+	aJson, _ := New().ParseWith([]byte(`{"compact": true, "schema": 0}`), ParseOptions{Ordered: true})
+	b, _ := aJson.ToMsgPack()
+	if result := hex.EncodeToString(b); result != "82a7636f6d70616374c3a6736368656d6100" {
+		t.Errorf("Expected 82a7636f6d70616374c3a6736368656d6100, but got %s", result)
+	}
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	aJson, _ := New().ParseWith([]byte(`{"i": 1, "f": 1.0, "neg": -200, "s": "text", "n": null, "list": [1.5, [], {}]}`), ParseOptions{Ordered: true})
+	aJson.Put(Object{"big": uint64(18446744073709551615)})
+
+	b, err := aJson.ToMsgPack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bJson, err := New().ParseMsgPack(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bJson.Type("i") != "int" || bJson.Type("f") != "float" || bJson.Type("big") != "number" {
+		t.Errorf("Expected int, float and number, but got %s %s %s", bJson.Type("i"), bJson.Type("f"), bJson.Type("big"))
+	}
+
+	expected := `{"i":1,"f":1,"neg":-200,"s":"text","n":null,"list":[1.5,[],{}],"big":18446744073709551615}`
+	if result := bJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+}
+
+func TestMsgPackTimestamp(t *testing.T) {
+	cases := map[string]string{
+		"d6ff00000000":                   "1970-01-01T00:00:00Z",
+		"d7ff7735940000000001":           "1970-01-01T00:00:01.5Z",
+		"c70cff00000000fffffffffffffff1": "1969-12-31T23:59:45Z",
+	}
+
+	for data, expected := range cases {
+		b, _ := hex.DecodeString(data)
+
+		aJson, err := New().ParseMsgPack(b)
+		if err != nil || aJson.ToString() != expected {
+			t.Errorf("Expected %s, but got %s (%v)", expected, aJson.ToString(), err)
+		}
+
+		out, _ := aJson.ToMsgPack(MsgPackOptions{Timestamps: true})
+		if hex.EncodeToString(out) != data {
+			t.Errorf("Expected %s, but got %x", data, out)
+		}
+	}
+
+	out, _ := NewString("2024-01-01T00:00:00Z").ToMsgPack()
+	if result := hex.EncodeToString(out); result[:2] != "b4" {
+		t.Errorf("Expected a plain string without Timestamps, but got %s", result)
+	}
+
+	// a zone offset or a different spelling would not survive the round trip
+	for _, each := range []string{"2024-01-01T09:00:00+09:00", "2024-01-01T00:00:00.500Z", "2024-01-01t00:00:00z"} {
+		out, _ := NewString(each).ToMsgPack(MsgPackOptions{Timestamps: true})
+		if aJson, err := New().ParseMsgPack(out); err != nil || aJson.String() != each {
+			t.Errorf("Expected %s, but got %s (%v)", each, aJson.ToString(), err)
+		}
+	}
+}
+
+func TestMsgPackErrors(t *testing.T) {
+	// ca7fc00000 is NaN and cbfff0000000000000 -Inf
+	for _, each := range []string{"c4026869", "d40100", "8101c0", "81a161ca7fc00000", "cbfff0000000000000"} {
+		b, _ := hex.DecodeString(each)
+		if _, err := New().ParseMsgPack(b); !errors.Is(err, ErrMsgPackUnsupported) {
+			t.Errorf("Expected ErrMsgPackUnsupported for %s, but got %v", each, err)
+		}
+	}
+
+	for _, each := range []string{"", "c1", "cd00", "92c0", "a3616263ff", "a1ff"} {
+		b, _ := hex.DecodeString(each)
+		if _, err := New().ParseMsgPack(b); err == nil || errors.Is(err, ErrMsgPackUnsupported) {
+			t.Errorf("Expected malformed error for %s, but got %v", each, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package djson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const excerptRadius = 16
+
+// Errors wrapped by ParseError when a ParseOptions limit is exceeded.
+
+var (
+	ErrMaxDepth     = errors.New("maximum depth exceeded")
+	ErrMaxBytes     = errors.New("maximum document size exceeded")
+	ErrMaxElements  = errors.New("maximum element count exceeded")
+	ErrMaxStringLen = errors.New("maximum string length exceeded")
+	ErrMaxKeyLen    = errors.New("maximum key length exceeded")
+)
+
+// ParseError describes where and why a document failed to parse.
+// Offset is the byte offset into the input, Line and Column are 1-based
+// (Column counts runes) and Excerpt is the text surrounding the failure.
+// Err is set for failures that have an error kind, e.g. ErrMaxDepth.
+
+type ParseError struct {
+	Msg     string
+	Offset  int64
+	Line    int
+	Column  int
+	Excerpt string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Excerpt == "" {
+		return fmt.Sprintf("%s at line %d, column %d", e.Msg, e.Line, e.Column)
+	}
+
+	return fmt.Sprintf("%s at line %d, column %d near %q", e.Msg, e.Line, e.Column, e.Excerpt)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newParseError(doc []byte, offset int64, msg string) *ParseError {
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset > int64(len(doc)) {
+		offset = int64(len(doc))
+	}
+
+	line := 1
+	lineStart := 0
+	for idx := 0; idx < int(offset); idx++ {
+		if doc[idx] == '\n' {
+			line++
+			lineStart = idx + 1
+		}
+	}
+
+	lineEnd := bytes.IndexByte(doc[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(doc)
+	} else {
+		lineEnd += int(offset)
+	}
+
+	from := int(offset) - excerptRadius
+	if from < lineStart {
+		from = lineStart
+	}
+	for from < int(offset) && !utf8.RuneStart(doc[from]) {
+		from++
+	}
+
+	to := int(offset) + excerptRadius
+	if to > lineEnd {
+		to = lineEnd
+	}
+	for to < lineEnd && !utf8.RuneStart(doc[to]) {
+		to++
+	}
+
+	return &ParseError{
+		Msg:     msg,
+		Offset:  offset,
+		Line:    line,
+		Column:  utf8.RuneCount(doc[lineStart:offset]) + 1,
+		Excerpt: string(bytes.TrimRight(doc[from:to], "\r")),
+	}
+}
+
+func firstNonSpace(doc []byte) int64 {
+	for idx := range doc {
+		switch doc[idx] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return int64(idx)
+	}
+
+	return int64(len(doc))
+}
+
+func decodeDocument(doc []byte, opts ParseOptions) (interface{}, error) {
+	return newParser(doc, opts).parseDocument()
+}
+
+func ParseBytesToObject(doc []byte) (*DO, error) {
+	data, err := decodeDocument(doc, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := data.(*DO)
+	if !ok {
+		return nil, newParseError(doc, firstNonSpace(doc), "not Object")
+	}
+
+	return obj, nil
+}
+
+func ParseBytesToArray(doc []byte) (*DA, error) {
+	data, err := decodeDocument(doc, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	arr, ok := data.(*DA)
+	if !ok {
+		return nil, newParseError(doc, firstNonSpace(doc), "not Array")
+	}
+
+	return arr, nil
+}
+
+func (m *JSON) setDecoded(data interface{}) *JSON {
+	switch t := data.(type) {
+	case *DO:
+		m._Object = t
+		m._Type = OBJECT
+	case *DA:
+		m._Array = t
+		m._Type = ARRAY
+	case int64:
+		m._Int = t
+		m._Type = INT
+	case float64:
+		m._Float = t
+		m._Type = FLOAT
+	case Number:
+		m._Number = t
+		m._Type = NUMBER
+	case string:
+		m._String = t
+		m._Type = STRING
+	case bool:
+		m._Bool = t
+		m._Type = BOOL
+	case nil:
+		m._Type = NULL
+	}
+
+	return m
+}
+
+// ParseE works like Parse but reports malformed input as *ParseError
+// instead of falling back to a STRING value. Anything but whitespace after
+// the value is malformed as well. Raw control characters inside strings
+// are still accepted as Parse does; ParseStrict rejects them too.
+
+func (m *JSON) ParseE(doc string) (*JSON, error) {
+	return m.ParseBytes([]byte(doc))
+}
+
+func (m *JSON) ParseBytes(doc []byte) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	p := newParser(doc, ParseOptions{})
+	p.single = true
+
+	data, err := p.parseDocument()
+	if err != nil {
+		return m, err
+	}
+
+	return m.setDecoded(data), nil
+}
+
+// ParseOptions controls how ParseWith reads a document. Without Strict or
+// Relaxed it is as lenient as Parse and ignores content after the value.
+// Strict accepts only a single RFC 8259 JSON text: no trailing content,
+// no bare words and no number or literal forms outside the JSON grammar.
+// Ordered builds ordered objects that keep the key order of the input.
+// PreserveNumbers keeps numeric literals as Number instead of converting
+// them to int64 or float64. PreservePrecision converts them as usual but
+// keeps a literal as Number when int64 or float64 would change its value,
+// such as 18446744073709551616 or 3.14159265358979323846.
+// Relaxed accepts JSON5 / JSONC input such as comments, trailing commas,
+// single-quoted strings and identifier keys. It takes precedence over
+// Strict.
+// DuplicateKeys decides what happens when an object repeats a key.
+//
+// The Max fields bound the resources a document may use; zero means no
+// limit, except that MaxDepth then defaults to 10000 as in encoding/json.
+// MaxDepth counts nested objects and arrays, MaxBytes the size of the
+// input, MaxElements all object members and array elements of the
+// document together, and MaxStringLen / MaxKeyLen the decoded byte length
+// of string values and object keys, checked while they are read.
+
+type ParseOptions struct {
+	Strict            bool
+	Relaxed           bool
+	Ordered           bool
+	PreserveNumbers   bool
+	PreservePrecision bool
+	DuplicateKeys     DuplicateKeyPolicy
+	MaxDepth          int
+	MaxBytes          int
+	MaxElements       int
+	MaxStringLen      int
+	MaxKeyLen         int
+}
+
+// DuplicateKeyPolicy values for ParseOptions.DuplicateKeys.
+// DuplicateLastWins keeps the last value (the key keeps its first position
+// in ordered objects), DuplicateFirstWins keeps the first value and
+// DuplicateError fails with a *ParseError naming the path of the key.
+
+type DuplicateKeyPolicy int
+
+const (
+	DuplicateLastWins DuplicateKeyPolicy = iota
+	DuplicateFirstWins
+	DuplicateError
+)
+
+func (m *JSON) ParseWith(doc []byte, opts ParseOptions) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	data, err := decodeDocument(doc, opts)
+	if err != nil {
+		return m, err
+	}
+
+	return m.setDecoded(data), nil
+}
+
+func (m *JSON) ParseStrict(doc string) (*JSON, error) {
+	return m.ParseWith([]byte(doc), ParseOptions{Strict: true})
+}
+
+func (m *JSON) ParseRelaxed(doc string) (*JSON, error) {
+	return m.ParseWith([]byte(doc), ParseOptions{Relaxed: true})
+}
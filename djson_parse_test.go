@@ -0,0 +1,171 @@
+package djson
+
+import (
+	"errors"
+	"log"
+	"testing"
+)
+
+func TestParseE(t *testing.T) {
+	aJson, err := New().ParseE(`{"name": "Hery Victor", "skills": ["Golang", "Java"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !aJson.IsObject() || aJson.StringPath(`["skills"][1]`) != "Java" {
+		t.Errorf("Expected object, but got %s", aJson.ToString())
+	}
+
+	bJson, err := New().ParseE(` 12 `)
+	if err != nil || !bJson.IsInt() || bJson.Int() != 12 {
+		t.Errorf("Expected 12, but got %s (%v)", bJson.ToString(), err)
+	}
+
+	cJson, err := New().ParseBytes([]byte(`"hello"`))
+	if err != nil || !cJson.IsString() || cJson.String() != "hello" {
+		t.Errorf("Expected hello, but got %s (%v)", cJson.ToString(), err)
+	}
+}
+
+func TestParseETrailing(t *testing.T) {
+	for _, each := range []string{`{"a":1} xyz`, `[1] [2]`, `12 13`} {
+		var pe *ParseError
+		if _, err := New().ParseE(each); !errors.As(err, &pe) {
+			t.Errorf("Expected *ParseError, but got %v for %s", err, each)
+		}
+
+		if _, err := New().ParseBytes([]byte(each)); !errors.As(err, &pe) {
+			t.Errorf("Expected *ParseError, but got %v for %s", err, each)
+		}
+	}
+
+	var pe *ParseError
+	if _, err := New().ParseE(`{"a":1} xyz`); !errors.As(err, &pe) || pe.Offset != 8 {
+		t.Errorf("Expected an error at offset 8, but got %v", err)
+	}
+
+	if aJson, err := New().ParseE("{\"a\":1} \n"); err != nil || aJson.Int("a") != 1 {
+		t.Errorf("Expected trailing whitespace to pass, but got %v", err)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	doc := "{\n  \"name\": \"kim\",\n  \"age\": 3,\n}"
+
+	aJson, err := New().ParseE(doc)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError, but got %v", err)
+	}
+
+	log.Println(pe.Error())
+
+	if !aJson.IsNull() {
+		t.Errorf("Expected null, but got %s", aJson.ToString())
+	}
+
+	if pe.Line != 4 || pe.Column != 1 || pe.Offset != int64(len(doc)-1) {
+		t.Errorf("Expected line 4 column 1, but got line %d column %d (offset %d)", pe.Line, pe.Column, pe.Offset)
+	}
+
+	_, err = New().ParseE("hello")
+	if !errors.As(err, &pe) || pe.Line != 1 || pe.Column != 1 || pe.Excerpt != "hello" {
+		t.Errorf("Expected error at 1:1, but got %v", err)
+	}
+
+	_, err = New().ParseE("  ")
+	if !errors.As(err, &pe) || pe.Offset != 2 {
+		t.Errorf("Expected error at end of input, but got %v", err)
+	}
+}
+
+func TestParseErrorColumnRunes(t *testing.T) {
+	_, err := New().ParseE(`{"병원": "록스" x}`)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError, but got %v", err)
+	}
+
+	if pe.Column != 13 {
+		t.Errorf("Expected column 13, but got %d", pe.Column)
+	}
+}
+
+func TestParseToObjectError(t *testing.T) {
+	_, err := ParseToObject(`[1,2,3]`)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Msg != "not Object" {
+		t.Errorf("Expected not Object, but got %v", err)
+	}
+
+	_, err = ParseToArray(`[1,2,`)
+	if !errors.As(err, &pe) {
+		t.Errorf("Expected *ParseError, but got %v", err)
+	}
+
+	arr, err := ParseBytesToArray([]byte(`[1,2,3]`))
+	if err != nil || arr.Size() != 3 {
+		t.Errorf("Expected 3 elements, but got %v", err)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	valid := []string{
+		`{"a": [1, -0.5, 2e10, 3E-2, true, false, null, "xé\n"]}`,
+		` [] `,
+		`"hello"`,
+		`0`,
+		`-12`,
+		`null`,
+	}
+
+	for _, doc := range valid {
+		if _, err := New().ParseStrict(doc); err != nil {
+			t.Errorf("Expected %s to be valid, but got %v", doc, err)
+		}
+	}
+
+	invalid := []string{
+		`hello`,
+		`{} junk`,
+		`[1,2]]`,
+		`+1`,
+		`0x10`,
+		`01`,
+		`1.`,
+		`.5`,
+		`NaN`,
+		`TRUE`,
+		`Null`,
+		`[1,]`,
+		`{"a":1,}`,
+		"[\"a\tb\"]",
+		`["\x"]`,
+		`{'a':1}`,
+		``,
+	}
+
+	for _, doc := range invalid {
+		_, err := New().ParseStrict(doc)
+
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Expected %s to be rejected, but got %v", doc, err)
+		}
+	}
+
+	_, err := New().ParseStrict(`{"a": 1} x`)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Column != 10 {
+		t.Errorf("Expected error at column 10, but got %v", err)
+	}
+
+	lenient, err := New().ParseWith([]byte(`{} junk`), ParseOptions{})
+	if err != nil || !lenient.IsObject() {
+		t.Errorf("Expected lenient parse to accept trailing content, but got %v", err)
+	}
+}
@@ -0,0 +1,649 @@
+package djson
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// parser reads a document in a single pass and builds *DO / *DA values
+// directly. Scalars become string, int64, float64, bool or nil, the same
+// types Put stores, or Number when numbers (or their precision) are
+// preserved.
+//
+// In strict mode the document must be exactly one RFC 8259 JSON text.
+// In relaxed mode JSON5 input is accepted (see djson_json5.go) and the
+// document must hold a single value. Otherwise raw control characters
+// inside strings are tolerated, and so is trailing content after the first
+// value unless single is set.
+
+type parser struct {
+	data            []byte
+	pos             int
+	strict          bool
+	relaxed         bool
+	single          bool
+	ordered         bool
+	preserveNumbers bool
+	precise         bool
+	duplicates      DuplicateKeyPolicy
+	path            []interface{} // tracked only for DuplicateError
+	depth           int
+	elements        int
+	limits          ParseOptions
+	buf             []byte
+}
+
+// parseMaxDepth bounds the nesting when ParseOptions.MaxDepth is zero, so a
+// deeply nested document fails with ErrMaxDepth instead of overflowing the
+// stack.
+
+const parseMaxDepth = 10000
+
+func newParser(doc []byte, opts ParseOptions) *parser {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = parseMaxDepth
+	}
+
+	return &parser{
+		data:            doc,
+		strict:          opts.Strict && !opts.Relaxed,
+		relaxed:         opts.Relaxed,
+		ordered:         opts.Ordered,
+		preserveNumbers: opts.PreserveNumbers,
+		precise:         opts.PreservePrecision,
+		duplicates:      opts.DuplicateKeys,
+		limits:          opts,
+	}
+}
+
+func (m *parser) fail(format string, v ...interface{}) error {
+	return newParseError(m.data, int64(m.pos), fmt.Sprintf(format, v...))
+}
+
+func (m *parser) failLimit(kind error, limit int) error {
+	pe := newParseError(m.data, int64(m.pos), fmt.Sprintf("%s (%d)", kind, limit))
+	pe.Err = kind
+	return pe
+}
+
+func (m *parser) failAtCursor(what string) error {
+	if m.pos >= len(m.data) {
+		return m.fail("unexpected end of input")
+	}
+
+	if m.relaxed && bytes.HasPrefix(m.data[m.pos:], []byte("/*")) {
+		return m.fail("unterminated comment")
+	}
+
+	r, _ := utf8.DecodeRune(m.data[m.pos:])
+	return m.fail("invalid character %q %s", r, what)
+}
+
+func (m *parser) skipSpace() {
+	for m.pos < len(m.data) {
+		switch m.data[m.pos] {
+		case ' ', '\t', '\r', '\n':
+			m.pos++
+		default:
+			if !m.relaxed || !m.skipRelaxedSpace() {
+				return
+			}
+		}
+	}
+}
+
+func (m *parser) parseDocument() (interface{}, error) {
+	if m.limits.MaxBytes > 0 && len(m.data) > m.limits.MaxBytes {
+		m.pos = m.limits.MaxBytes
+		return nil, m.failLimit(ErrMaxBytes, m.limits.MaxBytes)
+	}
+
+	m.skipSpace()
+
+	v, err := m.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if m.strict || m.relaxed || m.single {
+		m.skipSpace()
+
+		if m.pos < len(m.data) {
+			return nil, m.failAtCursor("after top-level value")
+		}
+	}
+
+	return v, nil
+}
+
+func (m *parser) parseValue() (interface{}, error) {
+	if m.pos >= len(m.data) {
+		return nil, m.fail("unexpected end of input")
+	}
+
+	switch c := m.data[m.pos]; {
+	case c == '{' || c == '[':
+		m.depth++
+		if m.depth > m.limits.MaxDepth {
+			return nil, m.failLimit(ErrMaxDepth, m.limits.MaxDepth)
+		}
+
+		var v interface{}
+		var err error
+		if c == '{' {
+			v, err = m.parseObject()
+		} else {
+			v, err = m.parseArray()
+		}
+
+		m.depth--
+		return v, err
+	case c == '"' || (m.relaxed && c == '\''):
+		return m.parseString(m.limits.MaxStringLen, ErrMaxStringLen)
+	case m.relaxed && (c == '-' || c == '+' || c == '.' || c == 'I' || c == 'N' || (c >= '0' && c <= '9')):
+		return m.parseRelaxedNumber()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return m.parseNumber()
+	case c == 't':
+		return true, m.parseLiteral("true")
+	case c == 'f':
+		return false, m.parseLiteral("false")
+	case c == 'n':
+		return nil, m.parseLiteral("null")
+	}
+
+	return nil, m.failAtCursor("looking for beginning of value")
+}
+
+func (m *parser) parseObject() (*DO, error) {
+	m.pos++ // {
+	m.skipSpace()
+
+	var obj *DO
+	if m.ordered {
+		obj = NewOrderedDO()
+	} else {
+		obj = NewDO()
+	}
+
+	if m.pos < len(m.data) && m.data[m.pos] == '}' {
+		m.pos++
+		return obj, nil
+	}
+
+	for {
+		keyStart := m.pos
+
+		if err := m.countElement(); err != nil {
+			return nil, err
+		}
+
+		key, err := m.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		// quoted keys are checked by parseString, identifier keys here
+		if m.limits.MaxKeyLen > 0 && len(key) > m.limits.MaxKeyLen {
+			m.pos = keyStart
+			return nil, m.failLimit(ErrMaxKeyLen, m.limits.MaxKeyLen)
+		}
+
+		_, dup := obj.Map[key]
+		if dup && m.duplicates == DuplicateError {
+			m.pos = keyStart
+			return nil, m.fail("duplicate key %s", formatPath(append(m.path, key)))
+		}
+
+		m.skipSpace()
+
+		if m.pos >= len(m.data) || m.data[m.pos] != ':' {
+			return nil, m.failAtCursor("after object key")
+		}
+
+		m.pos++
+		m.skipSpace()
+
+		if m.duplicates == DuplicateError {
+			m.path = append(m.path, key)
+		}
+
+		v, err := m.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if m.duplicates == DuplicateError {
+			m.path = m.path[:len(m.path)-1]
+		}
+
+		if !dup {
+			if m.ordered {
+				obj.order = append(obj.order, key)
+			}
+			obj.Map[key] = v
+		} else if m.duplicates == DuplicateLastWins {
+			obj.Map[key] = v
+		}
+
+		m.skipSpace()
+
+		if m.pos >= len(m.data) {
+			return nil, m.fail("unexpected end of input")
+		}
+
+		switch m.data[m.pos] {
+		case ',':
+			m.pos++
+			m.skipSpace()
+
+			if m.relaxed && m.pos < len(m.data) && m.data[m.pos] == '}' {
+				m.pos++
+				return obj, nil
+			}
+		case '}':
+			m.pos++
+			return obj, nil
+		default:
+			return nil, m.failAtCursor("after object value")
+		}
+	}
+}
+
+func (m *parser) parseArray() (*DA, error) {
+	m.pos++ // [
+	m.skipSpace()
+
+	arr := NewDA()
+
+	if m.pos < len(m.data) && m.data[m.pos] == ']' {
+		m.pos++
+		return arr, nil
+	}
+
+	for {
+		if err := m.countElement(); err != nil {
+			return nil, err
+		}
+
+		if m.duplicates == DuplicateError {
+			m.path = append(m.path, len(arr.Element))
+		}
+
+		v, err := m.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if m.duplicates == DuplicateError {
+			m.path = m.path[:len(m.path)-1]
+		}
+
+		arr.Element = append(arr.Element, v)
+
+		m.skipSpace()
+
+		if m.pos >= len(m.data) {
+			return nil, m.fail("unexpected end of input")
+		}
+
+		switch m.data[m.pos] {
+		case ',':
+			m.pos++
+			m.skipSpace()
+
+			if m.relaxed && m.pos < len(m.data) && m.data[m.pos] == ']' {
+				m.pos++
+				return arr, nil
+			}
+		case ']':
+			m.pos++
+			return arr, nil
+		default:
+			return nil, m.failAtCursor("after array element")
+		}
+	}
+}
+
+func (m *parser) parseKey() (string, error) {
+	if m.pos < len(m.data) {
+		switch c := m.data[m.pos]; {
+		case c == '"' || m.relaxed && c == '\'':
+			return m.parseString(m.limits.MaxKeyLen, ErrMaxKeyLen)
+		case m.relaxed:
+			return m.parseIdentifier()
+		}
+	}
+
+	return "", m.failAtCursor("looking for beginning of object key")
+}
+
+// countElement counts an object member or array element against
+// MaxElements, which limits the whole document.
+
+func (m *parser) countElement() error {
+	m.elements++
+	if m.limits.MaxElements > 0 && m.elements > m.limits.MaxElements {
+		return m.failLimit(ErrMaxElements, m.limits.MaxElements)
+	}
+	return nil
+}
+
+// parseString reads a string whose decoded length may not exceed limit
+// (if not zero). The length is checked while scanning, so an oversized
+// string is rejected before it is copied.
+
+func (m *parser) parseString(limit int, kind error) (string, error) {
+	quotePos := m.pos
+	quote := m.data[m.pos]
+	m.pos++
+	start := m.pos
+
+	tooLong := func() error {
+		m.pos = quotePos
+		return m.failLimit(kind, limit)
+	}
+
+	// fast path: no escapes
+	for m.pos < len(m.data) {
+		if limit > 0 && m.pos-start > limit {
+			return "", tooLong()
+		}
+
+		c := m.data[m.pos]
+
+		if c == quote {
+			s := string(m.data[start:m.pos])
+			m.pos++
+			return s, nil
+		}
+
+		if c == '\\' {
+			break
+		}
+
+		if c < 0x20 {
+			if m.strict {
+				return "", m.failAtCursor("in string literal")
+			}
+			m.pos++
+			continue
+		}
+
+		if c < utf8.RuneSelf {
+			m.pos++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(m.data[m.pos:])
+		if r == utf8.RuneError && size == 1 && m.strict {
+			return "", m.fail("invalid UTF-8 in string literal")
+		}
+		m.pos += size
+	}
+
+	if m.pos >= len(m.data) {
+		return "", m.fail("unexpected end of input")
+	}
+
+	m.buf = append(m.buf[:0], m.data[start:m.pos]...)
+
+	for m.pos < len(m.data) {
+		if limit > 0 && len(m.buf) > limit {
+			return "", tooLong()
+		}
+
+		c := m.data[m.pos]
+
+		switch {
+		case c == quote:
+			m.pos++
+			return string(m.buf), nil
+		case c == '\\':
+			if err := m.parseEscape(); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			if m.strict {
+				return "", m.failAtCursor("in string literal")
+			}
+			m.buf = append(m.buf, c)
+			m.pos++
+		case c < utf8.RuneSelf:
+			m.buf = append(m.buf, c)
+			m.pos++
+		default:
+			r, size := utf8.DecodeRune(m.data[m.pos:])
+			if r == utf8.RuneError && size == 1 && m.strict {
+				return "", m.fail("invalid UTF-8 in string literal")
+			}
+			m.buf = append(m.buf, m.data[m.pos:m.pos+size]...)
+			m.pos += size
+		}
+	}
+
+	return "", m.fail("unexpected end of input")
+}
+
+func (m *parser) parseEscape() error {
+	m.pos++ // backslash
+
+	if m.pos >= len(m.data) {
+		return m.fail("unexpected end of input")
+	}
+
+	c := m.data[m.pos]
+
+	switch c {
+	case '"', '\\', '/':
+		m.buf = append(m.buf, c)
+	case 'b':
+		m.buf = append(m.buf, '\b')
+	case 'f':
+		m.buf = append(m.buf, '\f')
+	case 'n':
+		m.buf = append(m.buf, '\n')
+	case 'r':
+		m.buf = append(m.buf, '\r')
+	case 't':
+		m.buf = append(m.buf, '\t')
+	case 'u':
+		m.pos++
+		r, err := m.parseHex(4, "in \\u escape")
+		if err != nil {
+			return err
+		}
+
+		if utf16.IsSurrogate(r) {
+			r2 := utf8.RuneError
+			if m.pos+1 < len(m.data) && m.data[m.pos] == '\\' && m.data[m.pos+1] == 'u' {
+				save := m.pos
+				m.pos += 2
+				if r2, err = m.parseHex(4, "in \\u escape"); err != nil {
+					return err
+				}
+				if r2 = utf16.DecodeRune(r, r2); r2 == utf8.RuneError {
+					m.pos = save
+				}
+			}
+			r = r2
+		}
+
+		m.buf = utf8.AppendRune(m.buf, r)
+		return nil
+	default:
+		if m.relaxed {
+			return m.parseRelaxedEscape(c)
+		}
+		return m.failAtCursor("in string escape code")
+	}
+
+	m.pos++
+	return nil
+}
+
+func (m *parser) parseHex(n int, what string) (rune, error) {
+	var r rune
+
+	for idx := 0; idx < n; idx++ {
+		if m.pos >= len(m.data) {
+			return 0, m.fail("unexpected end of input")
+		}
+
+		c := m.data[m.pos]
+
+		switch {
+		case c >= '0' && c <= '9':
+			r = r<<4 | rune(c-'0')
+		case c >= 'a' && c <= 'f':
+			r = r<<4 | rune(c-'a'+10)
+		case c >= 'A' && c <= 'F':
+			r = r<<4 | rune(c-'A'+10)
+		default:
+			return 0, m.failAtCursor(what)
+		}
+
+		m.pos++
+	}
+
+	return r, nil
+}
+
+func (m *parser) parseNumber() (interface{}, error) {
+	start := m.pos
+
+	isFloat, err := m.scanNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	return m.numberValue(string(m.data[start:m.pos]), isFloat, start)
+}
+
+// numberValue converts a JSON numeric literal that started at offset start.
+
+func (m *parser) numberValue(lit string, isFloat bool, start int) (interface{}, error) {
+	if m.preserveNumbers {
+		return Number(lit), nil
+	}
+
+	if !isFloat {
+		if i, err := strconv.ParseInt(lit, 10, 64); err == nil {
+			return i, nil
+		}
+		if m.precise {
+			return Number(lit), nil
+		}
+	}
+
+	f, err := strconv.ParseFloat(lit, 64)
+	if m.precise && (err != nil || !exactFloat(lit, f)) {
+		return Number(lit), nil
+	}
+	if err != nil {
+		m.pos = start
+		return nil, m.fail("number %s out of range", lit)
+	}
+
+	return f, nil
+}
+
+// exactFloat reports whether f, written back in its shortest form, has the
+// value of the decimal literal lit.
+
+func exactFloat(lit string, f float64) bool {
+	digits, zero := 0, true
+	for idx := 0; idx < len(lit) && lit[idx] != 'e' && lit[idx] != 'E'; idx++ {
+		if c := lit[idx]; c >= '0' && c <= '9' {
+			digits++
+			zero = zero && c == '0'
+		}
+	}
+
+	if f == 0 {
+		return zero
+	}
+
+	// up to 15 significant digits always survive a normal float64
+	if digits <= 15 && math.Abs(f) >= 0x1p-1022 {
+		return true
+	}
+
+	r, ok := new(big.Rat).SetString(lit)
+	if !ok {
+		return false
+	}
+
+	back, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return r.Cmp(back) == 0
+}
+
+// scanNumber moves past a numeric literal and reports whether it has a
+// fraction or an exponent part.
+
+func (m *parser) scanNumber() (bool, error) {
+	isFloat := false
+
+	if m.data[m.pos] == '-' {
+		m.pos++
+	}
+
+	if m.pos >= len(m.data) {
+		return false, m.fail("unexpected end of input")
+	}
+
+	switch c := m.data[m.pos]; {
+	case c == '0':
+		m.pos++
+	case c >= '1' && c <= '9':
+		m.skipDigits()
+	default:
+		return false, m.failAtCursor("in numeric literal")
+	}
+
+	if m.pos < len(m.data) && m.data[m.pos] == '.' {
+		isFloat = true
+		m.pos++
+		if !m.skipDigits() {
+			return false, m.failAtCursor("after decimal point in numeric literal")
+		}
+	}
+
+	if m.pos < len(m.data) && (m.data[m.pos] == 'e' || m.data[m.pos] == 'E') {
+		isFloat = true
+		m.pos++
+		if m.pos < len(m.data) && (m.data[m.pos] == '+' || m.data[m.pos] == '-') {
+			m.pos++
+		}
+		if !m.skipDigits() {
+			return false, m.failAtCursor("in exponent of numeric literal")
+		}
+	}
+
+	return isFloat, nil
+}
+
+func (m *parser) skipDigits() bool {
+	start := m.pos
+	for m.pos < len(m.data) && m.data[m.pos] >= '0' && m.data[m.pos] <= '9' {
+		m.pos++
+	}
+
+	return m.pos > start
+}
+
+func (m *parser) parseLiteral(lit string) error {
+	for idx := 0; idx < len(lit); idx++ {
+		if m.pos >= len(m.data) || m.data[m.pos] != lit[idx] {
+			return m.failAtCursor("in literal " + lit)
+		}
+		m.pos++
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package djson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestParserValues(t *testing.T) {
+	doc := `{
+		"int": 9223372036854775807,
+		"big": 92233720368547758070,
+		"neg": -3,
+		"float": 1.5e2,
+		"str": "tab\there \"quoted\" é 😀 \/",
+		"nested": [{"a": [true, false, null]}]
+	}`
+
+	obj, err := ParseToObject(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := obj.Get("int"); v != int64(9223372036854775807) {
+		t.Errorf("Expected int64 max, but got %v", v)
+	}
+
+	if v, _ := obj.Get("big"); v != float64(92233720368547758070) {
+		t.Errorf("Expected float fallback, but got %v", v)
+	}
+
+	if v, _ := obj.Get("float"); v != float64(150) {
+		t.Errorf("Expected 150, but got %v", v)
+	}
+
+	expected := "tab\there \"quoted\" é 😀 /"
+	if result := obj.String("str"); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	aJson := New().Put(obj)
+	if result := aJson.TypePath(`["nested"][0]["a"][2]`); result != "null" {
+		t.Errorf("Expected null, but got %s", result)
+	}
+
+	_, err = ParseToArray(`[1e999]`)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Offset != 1 {
+		t.Errorf("Expected out of range error at offset 1, but got %v", err)
+	}
+}
+
+func TestParserLenient(t *testing.T) {
+	aJson, err := New().ParseWith([]byte("{\"a\": \"raw\ttab\"} trailing"), ParseOptions{})
+	if err != nil || aJson.String("a") != "raw\ttab" {
+		t.Errorf("Expected lenient parse, but got %v", err)
+	}
+
+	if bJson, err := New().ParseE("{\"a\": \"raw\ttab\"}  "); err != nil || bJson.String("a") != "raw\ttab" {
+		t.Errorf("Expected raw control characters to pass, but got %v", err)
+	}
+
+	if _, err := New().ParseStrict("{\"a\": \"raw\ttab\"}"); err == nil {
+		t.Errorf("Expected strict parse to reject raw control characters")
+	}
+}
+
+func TestParserDuplicateKeys(t *testing.T) {
+	doc := []byte(`{"role": "user", "id": 1, "role": "admin"}`)
+
+	aJson, _ := New().ParseWith(doc, ParseOptions{})
+	if result := aJson.String("role"); result != "admin" {
+		t.Errorf("Expected admin, but got %s", result)
+	}
+
+	bJson, _ := New().ParseWith(doc, ParseOptions{DuplicateKeys: DuplicateFirstWins})
+	if result := bJson.String("role"); result != "user" {
+		t.Errorf("Expected user, but got %s", result)
+	}
+
+	cJson, _ := New().ParseWith(doc, ParseOptions{Ordered: true})
+	if result := cJson.ToString(); result != `{"role":"admin","id":1}` {
+		t.Errorf("Expected {\"role\":\"admin\",\"id\":1}, but got %s", result)
+	}
+
+	_, err := New().ParseWith(doc, ParseOptions{DuplicateKeys: DuplicateError})
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Msg != `duplicate key ["role"]` || pe.Offset != 26 {
+		t.Errorf("Expected duplicate key error at offset 26, but got %v", err)
+	}
+
+	nested := []byte(`{"a": {"x": 1}, "items": [{"id": 1}, {"id": 2, "id": 3}]}`)
+
+	_, err = New().ParseWith(nested, ParseOptions{DuplicateKeys: DuplicateError})
+	if !errors.As(err, &pe) || pe.Msg != `duplicate key ["items"][1]["id"]` {
+		t.Errorf("Expected duplicate key [\"items\"][1][\"id\"], but got %v", err)
+	}
+
+	if _, err := New().ParseWith([]byte(`[{"a": 1}, {"a": 2}]`), ParseOptions{DuplicateKeys: DuplicateError}); err != nil {
+		t.Errorf("Expected same key in sibling objects to pass, but got %v", err)
+	}
+}
+
+func TestParserDefaultDepth(t *testing.T) {
+	deep := strings.Repeat("[", 20000000)
+
+	if _, err := New().ParseE(deep); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("Expected ErrMaxDepth, but got %v", err)
+	}
+
+	if result := New().Parse(deep); !result.IsNull() {
+		t.Errorf("Expected null, but got %s", result.Type())
+	}
+
+	if err := json.Unmarshal([]byte(deep), New()); err == nil {
+		t.Errorf("Expected an error for deeply nested input")
+	}
+
+	nested := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	if _, err := New().ParseE(nested); err != nil {
+		t.Errorf("Expected 10000 levels to parse, but got %v", err)
+	}
+}
+
+func TestParserLimits(t *testing.T) {
+	cases := []struct {
+		doc  string
+		opts ParseOptions
+		kind error
+	}{
+		{`[[[1]]]`, ParseOptions{MaxDepth: 2}, ErrMaxDepth},
+		{`{"a": {"b": {}}}`, ParseOptions{MaxDepth: 2}, ErrMaxDepth},
+		{`[1, 2, 3]`, ParseOptions{MaxBytes: 8}, ErrMaxBytes},
+		{`[1, 2, 3]`, ParseOptions{MaxElements: 2}, ErrMaxElements},
+		{`{"a": 1, "b": 2, "c": 3}`, ParseOptions{MaxElements: 2}, ErrMaxElements},
+		{`{"a": "abcdef"}`, ParseOptions{MaxStringLen: 5}, ErrMaxStringLen},
+		{`["\u00e9\u00e9\u00e9"]`, ParseOptions{MaxStringLen: 5}, ErrMaxStringLen},
+		{`{"abcdef": 1}`, ParseOptions{MaxKeyLen: 5}, ErrMaxKeyLen},
+		{`{abcdef: 1}`, ParseOptions{Relaxed: true, MaxKeyLen: 5}, ErrMaxKeyLen},
+		{`[[1], [2], {"a": 3}]`, ParseOptions{MaxElements: 5}, ErrMaxElements},
+	}
+
+	for _, each := range cases {
+		_, err := New().ParseWith([]byte(each.doc), each.opts)
+		if !errors.Is(err, each.kind) {
+			t.Errorf("Expected %v for %s, but got %v", each.kind, each.doc, err)
+		}
+	}
+
+	// MaxElements counts 2 members and 1 + 3 elements
+	opts := ParseOptions{MaxDepth: 3, MaxBytes: 64, MaxElements: 6, MaxStringLen: 6, MaxKeyLen: 6}
+
+	aJson, err := New().ParseWith([]byte(`{"abcdef": [[1, 2, 3]], "b": "abcdef"}`), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := aJson.String("b"); result != "abcdef" {
+		t.Errorf("Expected abcdef, but got %s", result)
+	}
+
+	// an oversized string is rejected at its opening quote, before the end
+	// of the input is reached
+	_, err = New().ParseWith([]byte(`["ab", "abcdefgh`), ParseOptions{MaxStringLen: 4})
+
+	var limitErr *ParseError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrMaxStringLen) || limitErr.Offset != 7 {
+		t.Errorf("Expected string length error at offset 7, but got %v", err)
+	}
+
+	_, err = New().ParseWith([]byte(`{"a": [1, {"b": [2]}]}`), ParseOptions{MaxDepth: 3})
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Offset != 16 {
+		t.Errorf("Expected depth error at offset 16, but got %v", err)
+	}
+}
+
+func makeLargeDoc(n int) []byte {
+	var sb strings.Builder
+
+	sb.WriteString(`{"items":[`)
+	for idx := 0; idx < n; idx++ {
+		if idx > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id":%d,"name":"item %d","price":%d.25,"active":true,"tags":["a","b","c"],"owner":{"id":%d,"email":"user%d@example.com"}}`, idx, idx, idx, idx*7, idx)
+	}
+	sb.WriteString(`]}`)
+
+	return []byte(sb.String())
+}
+
+func BenchmarkParseNative(b *testing.B) {
+	doc := makeLargeDoc(5000)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(doc)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseBytesToObject(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseMapRebuild measures the former decode-to-map then
+// ParseObject path for comparison.
+
+func BenchmarkParseMapRebuild(b *testing.B) {
+	doc := makeLargeDoc(5000)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(doc)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var data map[string]interface{}
+
+		d := json.NewDecoder(strings.NewReader(string(doc)))
+		d.UseNumber()
+
+		if err := d.Decode(&data); err != nil {
+			b.Fatal(err)
+		}
+
+		_ = ParseObject(data)
+	}
+}
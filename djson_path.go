@@ -1,9 +1,38 @@
 package djson
 
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidPath  = errors.New("invalid path")
+	ErrPathConflict = errors.New("path conflict")
+)
+
+// PathConflict tells EnsurePath what to do with a value that is in the way
+// of the path, e.g. a string where an object is needed.
+
+type PathConflict int
+
+const (
+	ConflictError   PathConflict = iota // leave the value and return ErrPathConflict
+	ConflictReplace                     // replace the value with a new object or array
+)
+
+// ObjectPath and the other getters, ArrayPath, FloatPath, IntPath,
+// BoolPath, StringPath, TypePath and KeysPath, only read the document. An
+// index past the end of an array is a missing value; earlier releases
+// padded such an array with 0 first, as UpdatePath still does.
+
 func (m *JSON) ObjectPath(path string) (*JSON, bool) {
+	return m.objectPath(PathTokenizer(path))
+}
+
+func (m *JSON) objectPath(tokens []interface{}) (*JSON, bool) {
 	retJson := New()
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			if obj, ok := da.Object(idx); ok {
 				retJson._Object = obj
@@ -26,9 +55,13 @@ func (m *JSON) ObjectPath(path string) (*JSON, bool) {
 }
 
 func (m *JSON) ArrayPath(path string) (*JSON, bool) {
+	return m.arrayPath(PathTokenizer(path))
+}
+
+func (m *JSON) arrayPath(tokens []interface{}) (*JSON, bool) {
 	retJson := New()
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			if arr, ok := da.Array(idx); ok {
 				retJson._Array = arr
@@ -51,10 +84,14 @@ func (m *JSON) ArrayPath(path string) (*JSON, bool) {
 }
 
 func (m *JSON) FloatPath(path string, dv ...float64) float64 {
+	return m.floatPath(PathTokenizer(path), dv...)
+}
+
+func (m *JSON) floatPath(tokens []interface{}, dv ...float64) float64 {
 	var ret float64
 	var kok bool
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			ret, kok = da.Float(idx)
 		},
@@ -75,10 +112,14 @@ func (m *JSON) FloatPath(path string, dv ...float64) float64 {
 }
 
 func (m *JSON) IntPath(path string, dv ...int64) int64 {
+	return m.intPath(PathTokenizer(path), dv...)
+}
+
+func (m *JSON) intPath(tokens []interface{}, dv ...int64) int64 {
 	var ret int64
 	var kok bool
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			ret, kok = da.Int(idx)
 		},
@@ -99,10 +140,14 @@ func (m *JSON) IntPath(path string, dv ...int64) int64 {
 }
 
 func (m *JSON) BoolPath(path string, dv ...bool) bool {
+	return m.boolPath(PathTokenizer(path), dv...)
+}
+
+func (m *JSON) boolPath(tokens []interface{}, dv ...bool) bool {
 	var ret bool
 	var kok bool
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			ret, kok = da.Bool(idx)
 		},
@@ -123,9 +168,13 @@ func (m *JSON) BoolPath(path string, dv ...bool) bool {
 }
 
 func (m *JSON) StringPath(path string) string {
+	return m.stringPath(PathTokenizer(path))
+}
+
+func (m *JSON) stringPath(tokens []interface{}) string {
 	var ret string
 
-	_ = m.DoPathFunc(path, nil,
+	_ = m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			ret = da.String(idx)
 		},
@@ -138,9 +187,13 @@ func (m *JSON) StringPath(path string) string {
 }
 
 func (m *JSON) TypePath(path string) string {
+	return m.typePath(PathTokenizer(path))
+}
+
+func (m *JSON) typePath(tokens []interface{}) string {
 	var pathType string
 
-	_ = m.DoPathFunc(path, nil,
+	_ = m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			pathType, _ = da.Type(idx)
 		},
@@ -187,8 +240,16 @@ func (m *JSON) SortPath(path string, isAsc bool, k ...string) bool {
 	}
 }
 
+// RemovePath reports false for an index past the end of an array and leaves
+// the array as it is. Earlier releases padded the array with 0 and reported
+// true.
+
 func (m *JSON) RemovePath(path string) bool {
-	return m.DoPathFunc(path, nil,
+	return m.removePath(PathTokenizer(path))
+}
+
+func (m *JSON) removePath(tokens []interface{}) bool {
+	return m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			da.Remove(idx)
 		},
@@ -243,7 +304,11 @@ func (m *JSON) PushBackToPath(path string, val interface{}) bool {
 // Replace or insert a value
 
 func (m *JSON) UpdatePath(path string, val interface{}) bool {
-	return m.DoPathFunc(path, val,
+	return m.updatePath(PathTokenizer(path), val)
+}
+
+func (m *JSON) updatePath(tokens []interface{}, val interface{}) bool {
+	return m.doPathTokens(tokens, val,
 		func(da *DA, idx int, v interface{}) {
 			da.ReplaceAt(idx, v)
 		},
@@ -253,10 +318,143 @@ func (m *JSON) UpdatePath(path string, val interface{}) bool {
 	)
 }
 
+// EnsurePath is UpdatePath that creates what is missing along the way, like
+// mkdir -p: an object for a key and an array for an index. Arrays are
+// padded with 0 up to the index, as UpdatePath does, and a null value
+// counts as missing.
+// A NULL m becomes an object or array as well.
+
+func (m *JSON) EnsurePath(path string, val interface{}, conflict PathConflict) error {
+	tokens, err := tokenizePath(path)
+	if err != nil {
+		return err
+	}
+
+	return m.ensurePath(tokens, val, conflict)
+}
+
+func (m *JSON) ensurePath(tokens []interface{}, val interface{}, conflict PathConflict) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: empty path", ErrInvalidPath)
+	}
+
+	for idx := range tokens {
+		if i, isInt := tokens[idx].(int); isInt && i < 0 {
+			return fmt.Errorf("%w: negative index in %s", ErrInvalidPath, formatPath(tokens))
+		}
+	}
+
+	var cur interface{}
+
+	switch m._Type {
+	case OBJECT:
+		cur = m._Object
+	case ARRAY:
+		cur = m._Array
+	}
+
+	if container, created := ensureContainer(cur, tokens[0], false); created {
+		if m._Type != NULL && conflict != ConflictReplace {
+			return fmt.Errorf("%w at the root", ErrPathConflict)
+		}
+
+		*m = JSON{}
+		m.setDecoded(container)
+		cur = container
+	}
+
+	for idx := range tokens {
+		last := idx == len(tokens)-1
+
+		var next interface{}
+		var created bool
+
+		switch t := cur.(type) {
+		case *DO:
+			key := tokens[idx].(string)
+
+			if last {
+				t.Put(key, val)
+				return nil
+			}
+
+			if next, created = ensureContainer(t.Map[key], tokens[idx+1], t.ordered); !created {
+				break
+			}
+			if t.Map[key] != nil && conflict != ConflictReplace {
+				return fmt.Errorf("%w at %s", ErrPathConflict, formatPath(tokens[:idx+1]))
+			}
+			t.set(key, next)
+		case *DA:
+			i := tokens[idx].(int)
+
+			for t.Size() < i {
+				t.PushBack(0)
+			}
+
+			if last {
+				if i < len(t.Element) {
+					t.ReplaceAt(i, val)
+				} else {
+					t.PushBack(val)
+				}
+				return nil
+			}
+
+			if i == len(t.Element) {
+				t.Element = append(t.Element, nil)
+			}
+
+			if next, created = ensureContainer(t.Element[i], tokens[idx+1], false); !created {
+				break
+			}
+			if t.Element[i] != nil && conflict != ConflictReplace {
+				return fmt.Errorf("%w at %s", ErrPathConflict, formatPath(tokens[:idx+1]))
+			}
+			t.Element[i] = next
+		}
+
+		cur = next
+	}
+
+	return nil
+}
+
+// ensureContainer returns v if it can take token, i.e. it is an object for
+// a key or an array for an index, and otherwise a new empty one.
+
+func ensureContainer(v interface{}, token interface{}, ordered bool) (interface{}, bool) {
+	if _, isKey := token.(string); isKey {
+		if obj, ok := v.(*DO); ok {
+			return obj, false
+		}
+		if ordered {
+			return NewOrderedDO(), true
+		}
+		return NewDO(), true
+	}
+
+	if arr, ok := v.(*DA); ok {
+		return arr, false
+	}
+	return NewDA(), true
+}
+
 func (m *JSON) doPathFuncCore(
 	arrayTaskFunc func(da *DA, idx int, v interface{}),
 	objectTaskFunc func(do *DO, key string, v interface{}),
 	val interface{}, token ...interface{}) bool {
+	return m.walkPath(true, arrayTaskFunc, objectTaskFunc, val, token...)
+}
+
+// walkPath runs the task on the container holding the last token. With pad
+// the last array is padded with 0 up to the index first, otherwise an index
+// out of range fails and nothing along the path is modified.
+
+func (m *JSON) walkPath(pad bool,
+	arrayTaskFunc func(da *DA, idx int, v interface{}),
+	objectTaskFunc func(do *DO, key string, v interface{}),
+	val interface{}, token ...interface{}) bool {
 
 	jsonMode := m._Type
 	dObject := m._Object
@@ -297,14 +495,22 @@ func (m *JSON) doPathFuncCore(
 				return false
 			}
 
-			for dArray.Size() < tkey {
-				dArray.PushBack(0)
-			}
-
 			if idx == tokenLen-1 {
+				if !pad && (tkey < 0 || tkey >= dArray.Size()) {
+					return false
+				}
+
+				for dArray.Size() < tkey {
+					dArray.PushBack(0)
+				}
+
 				arrayTaskFunc(dArray, tkey, val)
 				return true
 			} else {
+				if tkey < 0 || tkey >= dArray.Size() {
+					return false
+				}
+
 				switch t := dArray.Element[tkey].(type) {
 				case *DO:
 					dObject = t
@@ -333,22 +539,37 @@ func (m *JSON) DoPathFunc(path string, val interface{},
 	return m.doPathFuncCore(arrayTaskFunc, objectTaskFunc, val, PathTokenizer(path)...)
 }
 
+func (m *JSON) doPathTokens(tokens []interface{}, val interface{},
+	arrayTaskFunc func(da *DA, idx int, v interface{}),
+	objectTaskFunc func(do *DO, key string, v interface{})) bool {
+	return m.doPathFuncCore(arrayTaskFunc, objectTaskFunc, val, tokens...)
+}
+
+// readPathTokens is doPathTokens for reads and removal, which never pad an
+// array that is too short.
+
+func (m *JSON) readPathTokens(tokens []interface{},
+	arrayTaskFunc func(da *DA, idx int, v interface{}),
+	objectTaskFunc func(do *DO, key string, v interface{})) bool {
+	return m.walkPath(false, arrayTaskFunc, objectTaskFunc, nil, tokens...)
+}
+
 func (m *JSON) KeysPath(path string) ([]string, bool) {
+	return m.keysPath(PathTokenizer(path))
+}
+
+func (m *JSON) keysPath(tokens []interface{}) ([]string, bool) {
 	rk := make([]string, 0)
 
-	pok := m.DoPathFunc(path, nil,
+	pok := m.readPathTokens(tokens,
 		func(da *DA, idx int, v interface{}) {
 			if ddo, ok := da.Object(idx); ok {
-				for k := range ddo.Map {
-					rk = append(rk, k)
-				}
+				rk = append(rk, ddo.Keys()...)
 			}
 		},
 		func(do *DO, key string, v interface{}) {
 			if ddo, ok := do.Object(key); ok {
-				for k := range ddo.Map {
-					rk = append(rk, k)
-				}
+				rk = append(rk, ddo.Keys()...)
 			}
 		},
 	)
@@ -359,3 +580,110 @@ func (m *JSON) KeysPath(path string) ([]string, bool) {
 
 	return rk, true
 }
+
+// Path is a path compiled by CompilePath. It is tokenized once and never
+// modified, so one Path can be shared between goroutines. Reads through it
+// do not modify the document, but a document that is being written must
+// not be read at the same time. Its methods behave like the matching *Path
+// methods of JSON.
+
+type Path struct {
+	path   string
+	tokens []interface{}
+}
+
+// CompilePath tokenizes path as PathTokenizer does and reports an unclosed
+// bracket or quote.
+
+func CompilePath(path string) (*Path, error) {
+	tokens, err := tokenizePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Path{path: path, tokens: tokens}, nil
+}
+
+func MustCompilePath(path string) *Path {
+	p, err := CompilePath(path)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+func (p *Path) Source() string {
+	return p.path
+}
+
+// Get returns the value at the path, sharing objects and arrays as Get
+// does. The empty path is m itself.
+
+func (p *Path) Get(m *JSON) (*JSON, bool) {
+	if len(p.tokens) == 0 {
+		return m, true
+	}
+
+	var elem interface{}
+	var found bool
+
+	pok := m.readPathTokens(p.tokens,
+		func(da *DA, idx int, v interface{}) {
+			elem, found = da.Get(idx)
+		},
+		func(do *DO, key string, v interface{}) {
+			elem, found = do.Map[key]
+		},
+	)
+
+	if !pok || !found {
+		return nil, false
+	}
+
+	return wrapElement(elem)
+}
+
+func (p *Path) Set(m *JSON, val interface{}) bool {
+	return m.updatePath(p.tokens, val)
+}
+
+func (p *Path) Remove(m *JSON) bool {
+	return m.removePath(p.tokens)
+}
+
+func (p *Path) Object(m *JSON) (*JSON, bool) {
+	return m.objectPath(p.tokens)
+}
+
+func (p *Path) Array(m *JSON) (*JSON, bool) {
+	return m.arrayPath(p.tokens)
+}
+
+func (p *Path) Int(m *JSON, dv ...int64) int64 {
+	return m.intPath(p.tokens, dv...)
+}
+
+func (p *Path) Float(m *JSON, dv ...float64) float64 {
+	return m.floatPath(p.tokens, dv...)
+}
+
+func (p *Path) Bool(m *JSON, dv ...bool) bool {
+	return m.boolPath(p.tokens, dv...)
+}
+
+func (p *Path) String(m *JSON) string {
+	return m.stringPath(p.tokens)
+}
+
+func (p *Path) Type(m *JSON) string {
+	return m.typePath(p.tokens)
+}
+
+func (p *Path) Keys(m *JSON) ([]string, bool) {
+	return m.keysPath(p.tokens)
+}
+
+func (p *Path) Ensure(m *JSON, val interface{}, conflict PathConflict) error {
+	return m.ensurePath(p.tokens, val, conflict)
+}
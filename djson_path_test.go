@@ -1,6 +1,7 @@
 package djson
 
 import (
+	"errors"
 	"log"
 	"testing"
 )
@@ -160,3 +161,203 @@ func TestUpdatePath2(t *testing.T) {
 	log.Println(bJson.ToString())
 
 }
+
+func TestDotPath(t *testing.T) {
+	aJson := New().Parse(`{"user": {"profile": {"name": "kim", "a.b": 1}},
+		"items": [{"sku": "x1"}, {"sku": "x2", "qty": 2}]}`)
+
+	if result := aJson.StringPath(`user.profile.name`); result != "kim" {
+		t.Errorf("Expected kim, but got %s", result)
+	}
+
+	if result := aJson.IntPath(`user.profile.a\.b`); result != 1 {
+		t.Errorf("Expected 1, but got %d", result)
+	}
+
+	if result := aJson.IntPath(` ["user"]["profile"]["a.b"]`); result != 1 {
+		t.Errorf("Expected 1, but got %d", result)
+	}
+
+	if result := aJson.IntPath(`items[1].qty`); result != 2 {
+		t.Errorf("Expected 2, but got %d", result)
+	}
+
+	if !aJson.UpdatePath(`items[0].sku`, "y1") || aJson.StringPath(`["items"][0]["sku"]`) != "y1" {
+		t.Errorf("Expected y1, but got %s", aJson.StringPath(`["items"][0]["sku"]`))
+	}
+
+	if !aJson.RemovePath(`items.1.qty`) || aJson.TypePath(`items[1].qty`) != "" {
+		t.Errorf("Expected qty to be removed, but got %s", aJson.ToString())
+	}
+}
+
+func TestPathPadding(t *testing.T) {
+	aJson := New().Parse(`{"a": [1]}`)
+
+	// getters and RemovePath used to pad a to [1,0,0]
+	if aJson.IntPath(`a[2]`, -1) != -1 || aJson.StringPath(`a[2]`) != "" || aJson.RemovePath(`a[2]`) {
+		t.Errorf("Expected nothing at a[2]")
+	}
+
+	if result := aJson.ToString(); result != `{"a":[1]}` {
+		t.Errorf("Expected {\"a\":[1]}, but got %s", result)
+	}
+
+	// UpdatePath still pads up to the index
+	_ = aJson.UpdatePath(`a[3]`, 9)
+	if result := aJson.ToString(); result != `{"a":[1,0,0]}` {
+		t.Errorf("Expected {\"a\":[1,0,0]}, but got %s", result)
+	}
+}
+
+func TestCompilePath(t *testing.T) {
+	aJson := New().Parse(`{"items": [{"sku": "x1", "qty": 1, "price": 2.5, "tags": ["a"]}], "ok": true}`)
+
+	qty := MustCompilePath(`items[0].qty`)
+	if result := qty.Int(aJson); result != 1 {
+		t.Errorf("Expected 1, but got %d", result)
+	}
+
+	if !qty.Set(aJson, 5) || aJson.IntPath(`["items"][0]["qty"]`) != 5 {
+		t.Errorf("Expected 5, but got %d", aJson.IntPath(`["items"][0]["qty"]`))
+	}
+
+	if result := MustCompilePath(`["items"][0]["sku"]`).String(aJson); result != "x1" {
+		t.Errorf("Expected x1, but got %s", result)
+	}
+
+	if result := MustCompilePath(`items.0.price`).Float(aJson); result != 2.5 {
+		t.Errorf("Expected 2.5, but got %v", result)
+	}
+
+	if result := MustCompilePath(`ok`).Bool(aJson); !result {
+		t.Errorf("Expected true, but got %v", result)
+	}
+
+	if result := MustCompilePath(`missing`).Int(aJson, -1); result != -1 {
+		t.Errorf("Expected -1, but got %d", result)
+	}
+
+	// objects and arrays are shared
+	tags, ok := MustCompilePath(`items[0].tags`).Get(aJson)
+	if !ok || !tags.IsArray() {
+		t.Fatalf("Expected array, but got %v", tags)
+	}
+	tags.PutArray("b")
+	if result := aJson.StringPath(`items[0].tags[1]`); result != "b" {
+		t.Errorf("Expected b, but got %s", result)
+	}
+
+	if root, ok := MustCompilePath(``).Get(aJson); !ok || root != aJson {
+		t.Errorf("Expected the document itself for the empty path")
+	}
+
+	before := aJson.ToString()
+	if _, ok := MustCompilePath(`items[3].sku`).Get(aJson); ok {
+		t.Errorf("Expected no value past the end of the array")
+	}
+
+	if _, ok := MustCompilePath(`items[3]`).Get(aJson); ok || MustCompilePath(`items[5]`).Int(aJson, -1) != -1 {
+		t.Errorf("Expected no value past the end of the array")
+	}
+
+	if aJson.TypePath(`items[4]`) != "" || aJson.RemovePath(`items[4]`) {
+		t.Errorf("Expected nothing at items[4]")
+	}
+
+	if result := aJson.ToString(); result != before {
+		t.Errorf("Expected %s, but got %s", before, result)
+	}
+
+	if !MustCompilePath(`items[0].tags`).Remove(aJson) || aJson.TypePath(`items[0].tags`) != "" {
+		t.Errorf("Expected tags to be removed, but got %s", aJson.ToString())
+	}
+
+	for _, each := range []string{`["items"`, `[0]["a]`, `a\`, `items[0]junk`, `["items"][0]sku`, `[0]\a`} {
+		if _, err := CompilePath(each); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected ErrInvalidPath, but got %v for %s", err, each)
+		}
+	}
+}
+
+func BenchmarkIntPath(b *testing.B) {
+	aJson := New().Parse(`{"user": {"profile": {"age": 30}}, "items": [{"qty": 1}, {"qty": 2}]}`)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = aJson.IntPath(`["items"][1]["qty"]`)
+	}
+}
+
+func BenchmarkCompiledPathInt(b *testing.B) {
+	aJson := New().Parse(`{"user": {"profile": {"age": 30}}, "items": [{"qty": 1}, {"qty": 2}]}`)
+	p := MustCompilePath(`["items"][1]["qty"]`)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = p.Int(aJson)
+	}
+}
+
+func TestEnsurePath(t *testing.T) {
+	aJson := New()
+
+	if err := aJson.EnsurePath(`a.b.c`, 1, ConflictError); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.EnsurePath(`a.list[2].name`, "x", ConflictError); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.EnsurePath(`a["b"]["d"]`, true, ConflictError); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"a":{"b":{"c":1,"d":true},"list":[0,0,{"name":"x"}]}}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	err := aJson.EnsurePath(`a.b.c.e`, 2, ConflictError)
+	if !errors.Is(err, ErrPathConflict) {
+		t.Errorf("Expected ErrPathConflict, but got %v", err)
+	}
+	if result := aJson.IntPath(`a.b.c`); result != 1 {
+		t.Errorf("Expected 1, but got %d", result)
+	}
+
+	if err := aJson.EnsurePath(`a.b.c.e`, 2, ConflictReplace); err != nil {
+		t.Fatal(err)
+	}
+	if result := aJson.IntPath(`a.b.c.e`); result != 2 {
+		t.Errorf("Expected 2, but got %d", result)
+	}
+
+	// an object where an array is needed is a conflict as well
+	if err := aJson.EnsurePath(`a.b[0]`, 1, ConflictError); !errors.Is(err, ErrPathConflict) {
+		t.Errorf("Expected ErrPathConflict, but got %v", err)
+	}
+
+	bJson := New()
+	if err := MustCompilePath(`[1][0]`).Ensure(bJson, "v", ConflictError); err != nil {
+		t.Fatal(err)
+	}
+	if result := bJson.ToString(); result != `[0,["v"]]` {
+		t.Errorf("Expected [0,[\"v\"]], but got %s", result)
+	}
+
+	cJson := New().Put("scalar")
+	if err := cJson.EnsurePath(`a`, 1, ConflictError); !errors.Is(err, ErrPathConflict) {
+		t.Errorf("Expected ErrPathConflict, but got %v", err)
+	}
+	if err := cJson.EnsurePath(`a`, 1, ConflictReplace); err != nil || cJson.ToString() != `{"a":1}` {
+		t.Errorf("Expected {\"a\":1}, but got %s, %v", cJson.ToString(), err)
+	}
+
+	for _, each := range []string{``, `a[-1]`, `a["b`} {
+		if err := New().EnsurePath(each, 1, ConflictReplace); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Expected ErrInvalidPath, but got %v for %s", err, each)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package djson
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSON Pointer (RFC 6901) addresses a value with "/"-separated reference
+// tokens, e.g. /items/0/name, where "~1" stands for "/" and "~0" for "~".
+// The empty pointer is the whole document, and "-" is the position after
+// the last array element.
+
+var (
+	ErrInvalidPointer  = errors.New("invalid JSON Pointer")
+	ErrPointerNotFound = errors.New("JSON Pointer not found")
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func pointerSegments(ptr string) ([]string, error) {
+	if ptr == "" {
+		return []string{}, nil
+	}
+
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPointer, ptr)
+	}
+
+	segs := strings.Split(ptr[1:], "/")
+	for idx, seg := range segs {
+		if !strings.Contains(seg, "~") {
+			continue
+		}
+
+		var sb strings.Builder
+		for pos := 0; pos < len(seg); pos++ {
+			if seg[pos] != '~' {
+				sb.WriteByte(seg[pos])
+				continue
+			}
+
+			if pos+1 >= len(seg) || seg[pos+1] != '0' && seg[pos+1] != '1' {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidPointer, ptr)
+			}
+
+			if seg[pos+1] == '0' {
+				sb.WriteByte('~')
+			} else {
+				sb.WriteByte('/')
+			}
+			pos++
+		}
+		segs[idx] = sb.String()
+	}
+
+	return segs, nil
+}
+
+// ParsePointer converts a JSON Pointer into path tokens as returned by
+// PathTokenizer. Array indexes become ints and everything else strings.
+
+func ParsePointer(ptr string) ([]interface{}, error) {
+	segs, err := pointerSegments(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]interface{}, len(segs))
+	for idx, seg := range segs {
+		if i, ok := pointerIndex(seg); ok {
+			tokens[idx] = i
+		} else {
+			tokens[idx] = seg
+		}
+	}
+
+	return tokens, nil
+}
+
+// FormatPointer is the inverse of ParsePointer.
+
+func FormatPointer(tokens []interface{}) string {
+	var sb strings.Builder
+
+	for _, each := range tokens {
+		sb.WriteByte('/')
+		switch t := each.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(t))
+		case string:
+			sb.WriteString(pointerEscaper.Replace(t))
+		}
+	}
+
+	return sb.String()
+}
+
+// pointerIndex parses an array index token, which has no sign and no
+// leading zeros.
+
+func pointerIndex(seg string) (int, bool) {
+	if seg == "" || len(seg) > 1 && seg[0] == '0' {
+		return 0, false
+	}
+
+	for idx := 0; idx < len(seg); idx++ {
+		if seg[idx] < '0' || seg[idx] > '9' {
+			return 0, false
+		}
+	}
+
+	i, err := strconv.Atoi(seg)
+	if err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
+
+func (m *JSON) getSegments(segs []string) (*JSON, bool) {
+	cur := m
+
+	for _, seg := range segs {
+		var ok bool
+
+		switch cur._Type {
+		case OBJECT:
+			// Get("") would return cur itself
+			var elem interface{}
+			if elem, ok = cur._Object.Get(seg); ok {
+				cur, ok = wrapElement(elem)
+			}
+		case ARRAY:
+			idx, valid := pointerIndex(seg)
+			if !valid {
+				return nil, false
+			}
+			cur, ok = cur.Get(idx)
+		}
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// GetPointer returns the value at ptr. Objects and arrays are shared, as
+// with Get.
+
+func (m *JSON) GetPointer(ptr string) (*JSON, bool) {
+	segs, err := pointerSegments(ptr)
+	if err != nil {
+		return nil, false
+	}
+
+	return m.getSegments(segs)
+}
+
+// SetPointer adds or replaces the value at ptr. The parent has to exist;
+// in an array the index has to be an existing element, or the array size
+// ("-" also works) to append.
+
+func (m *JSON) SetPointer(ptr string, v interface{}) error {
+	segs, err := pointerSegments(ptr)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) == 0 {
+		nv, err := normalizeValue(v)
+		if err != nil {
+			return err
+		}
+
+		*m = JSON{}
+		switch t := nv.(type) {
+		case *DO:
+			m._Object, m._Type = t, OBJECT
+		case *DA:
+			m._Array, m._Type = t, ARRAY
+		default:
+			m.Put(t)
+		}
+		return nil
+	}
+
+	parent, ok := m.getSegments(segs[:len(segs)-1])
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrPointerNotFound, ptr)
+	}
+
+	last := segs[len(segs)-1]
+
+	switch parent._Type {
+	case OBJECT:
+		parent._Object.Put(last, v)
+		return nil
+	case ARRAY:
+		size := parent._Array.Size()
+
+		idx, valid := pointerIndex(last)
+		if last == "-" {
+			idx, valid = size, true
+		}
+
+		if valid && idx < size {
+			parent._Array.ReplaceAt(idx, v)
+			return nil
+		}
+		if valid && idx == size {
+			parent._Array.PushBack(v)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrPointerNotFound, ptr)
+}
+
+// RemovePointer removes the value at ptr. Later array elements move up.
+
+func (m *JSON) RemovePointer(ptr string) error {
+	segs, err := pointerSegments(ptr)
+	if err != nil {
+		return err
+	}
+
+	if len(segs) == 0 {
+		return fmt.Errorf("%w: cannot remove the document", ErrInvalidPointer)
+	}
+
+	parent, ok := m.getSegments(segs[:len(segs)-1])
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrPointerNotFound, ptr)
+	}
+
+	last := segs[len(segs)-1]
+
+	switch parent._Type {
+	case OBJECT:
+		if parent._Object.HasKey(last) {
+			parent._Object.Remove(last)
+			return nil
+		}
+	case ARRAY:
+		if idx, valid := pointerIndex(last); valid && idx < parent._Array.Size() {
+			parent._Array.Remove(idx)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrPointerNotFound, ptr)
+}
@@ -0,0 +1,146 @@
+package djson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetPointer(t *testing.T) {
+	// RFC 6901, section 5
+	aJson := New().Parse(`{"foo": ["bar", "baz"], "": 0, "a/b": 1, "c%d": 2, "e^f": 3, "g|h": 4, "i\\j": 5, "k\"l": 6, " ": 7, "m~n": 8}`)
+
+	cases := []struct {
+		ptr      string
+		expected string
+	}{
+		{"", aJson.ToString()},
+		{"/foo", `["bar","baz"]`},
+		{"/foo/0", "bar"},
+		{"/", "0"},
+		{"/a~1b", "1"},
+		{"/c%d", "2"},
+		{"/e^f", "3"},
+		{"/g|h", "4"},
+		{"/i\\j", "5"},
+		{"/k\"l", "6"},
+		{"/ ", "7"},
+		{"/m~0n", "8"},
+	}
+
+	for _, each := range cases {
+		v, ok := aJson.GetPointer(each.ptr)
+		if !ok {
+			t.Errorf("Expected %s, but got nothing for %q", each.expected, each.ptr)
+			continue
+		}
+
+		if result := v.ToString(); result != each.expected {
+			t.Errorf("Expected %s, but got %s for %q", each.expected, result, each.ptr)
+		}
+	}
+
+	for _, each := range []string{"foo", "/foo/2", "/foo/01", "/foo/-", "/foo/0/x", "/missing", "/m~2n"} {
+		if _, ok := aJson.GetPointer(each); ok {
+			t.Errorf("Expected nothing for %q", each)
+		}
+	}
+}
+
+func TestSetPointer(t *testing.T) {
+	aJson := New().Parse(`{"items": [{"id": 1}], "0": "key"}`)
+
+	if err := aJson.SetPointer("/items/0/name", "pen"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.SetPointer("/items/-", Object{"id": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.SetPointer("/items/2", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.SetPointer("/0", "zero"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"0":"zero","items":[{"id":1,"name":"pen"},{"id":2},3]}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	for _, each := range []string{"/items/5", "/missing/key", "/items/0/id/x", "items"} {
+		if err := aJson.SetPointer(each, 1); err == nil {
+			t.Errorf("Expected error for %q", each)
+		}
+	}
+
+	if err := aJson.SetPointer("/missing/key", 1); !errors.Is(err, ErrPointerNotFound) {
+		t.Errorf("Expected ErrPointerNotFound, but got %v", err)
+	}
+
+	if err := aJson.SetPointer("x", 1); !errors.Is(err, ErrInvalidPointer) {
+		t.Errorf("Expected ErrInvalidPointer, but got %v", err)
+	}
+
+	if err := aJson.SetPointer("", Array{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := aJson.ToString(); result != "[1,2]" {
+		t.Errorf("Expected [1,2], but got %s", result)
+	}
+}
+
+func TestRemovePointer(t *testing.T) {
+	aJson := New().Parse(`{"a/b": [1, 2, 3], "c": {"d": true}}`)
+
+	if err := aJson.RemovePointer("/a~1b/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aJson.RemovePointer("/c/d"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"a/b":[1,3],"c":{}}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	for _, each := range []string{"", "/a~1b/2", "/a~1b/-", "/c/d", "/x/y"} {
+		if err := aJson.RemovePointer(each); err == nil {
+			t.Errorf("Expected error for %q", each)
+		}
+	}
+}
+
+func TestPointerTokens(t *testing.T) {
+	tokens, err := ParsePointer("/items/0/a~1b/~0/-/01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{"items", 0, "a/b", "~", "-", "01"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, but got %v", expected, tokens)
+	}
+
+	if result := FormatPointer(tokens); result != "/items/0/a~1b/~0/-/01" {
+		t.Errorf("Expected /items/0/a~1b/~0/-/01, but got %s", result)
+	}
+
+	if result := FormatPointer(PathTokenizer(`["items"][0]["id"]`)); result != "/items/0/id" {
+		t.Errorf("Expected /items/0/id, but got %s", result)
+	}
+
+	if result := formatPath(tokens[:3]); result != `["items"][0]["a/b"]` {
+		t.Errorf("Expected [\"items\"][0][\"a/b\"], but got %s", result)
+	}
+
+	if _, err := ParsePointer("/a~"); !errors.Is(err, ErrInvalidPointer) {
+		t.Errorf("Expected ErrInvalidPointer, but got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package djson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RowsOptions controls FromRows.
+// Rename maps a column name to the object key; nil keeps column names.
+// JSONColumns lists columns whose text is parsed as a nested JSON value,
+// and ParseJSON does the same for every column whose database type is
+// JSON or JSONB.
+
+type RowsOptions struct {
+	Rename      func(column string) string
+	JSONColumns []string
+	ParseJSON   bool
+}
+
+// FromRows reads the remaining rows into an ARRAY of OBJECTs keyed by
+// column name, in column order. The caller still owns rows and closes it.
+// Text and byte columns become strings (invalid UTF-8 is base64 encoded as
+// in encoding/json), time.Time becomes an RFC 3339 string and NULL becomes
+// null. DECIMAL and NUMERIC columns read as text become NUMBER, so they
+// keep all their digits; text that is not a JSON number, such as NaN,
+// stays a string.
+
+func FromRows(rows *sql.Rows, opts ...RowsOptions) (*JSON, error) {
+	var opt RowsOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(columns))
+	kinds := make([]columnKind, len(columns))
+	dest := make([]interface{}, len(columns))
+
+	for idx, col := range columns {
+		keys[idx] = col.Name()
+		if opt.Rename != nil {
+			keys[idx] = opt.Rename(col.Name())
+		}
+
+		switch strings.ToUpper(col.DatabaseTypeName()) {
+		case "DECIMAL", "NUMERIC":
+			kinds[idx] = columnNumber
+		case "JSON", "JSONB":
+			if opt.ParseJSON {
+				kinds[idx] = columnJSON
+			}
+		}
+
+		for _, each := range opt.JSONColumns {
+			if each == col.Name() {
+				kinds[idx] = columnJSON
+			}
+		}
+
+		scanType := col.ScanType()
+		if scanType == nil || scanType.Kind() == reflect.Interface {
+			dest[idx] = new(interface{})
+		} else {
+			dest[idx] = reflect.New(scanType).Interface()
+		}
+	}
+
+	arr := NewDA()
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		obj := NewOrderedDO()
+
+		for idx := range dest {
+			v, err := rowValue(reflect.ValueOf(dest[idx]).Elem().Interface(), kinds[idx])
+			if err != nil {
+				return nil, err
+			}
+			obj.Put(keys[idx], v)
+		}
+
+		arr.Put(obj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return New().Put(arr), nil
+}
+
+// columnKind tells rowValue how to read the text of a column.
+
+type columnKind int
+
+const (
+	columnText columnKind = iota
+	columnJSON
+	columnNumber
+)
+
+func rowValue(v interface{}, kind columnKind) (interface{}, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		if t == nil {
+			return nil, nil
+		}
+		if kind != columnText {
+			return rowValue(string(t), kind)
+		}
+		if !utf8.Valid(t) {
+			return base64.StdEncoding.EncodeToString(t), nil
+		}
+		return string(t), nil
+	case sql.RawBytes:
+		return rowValue([]byte(t), kind)
+	case string:
+		switch kind {
+		case columnJSON:
+			return decodeDocument([]byte(t), columnOptions)
+		case columnNumber:
+			if Number(t).IsValid() {
+				return Number(t), nil
+			}
+		}
+		return t, nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case driver.Valuer:
+		// sql.NullString, sql.NullInt64, sql.NullTime, ...
+		dv, err := t.Value()
+		if err != nil {
+			return nil, err
+		}
+		return rowValue(dv, kind)
+	}
+
+	return v, nil
+}
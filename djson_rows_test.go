@@ -0,0 +1,217 @@
+package djson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeDriver serves a fixed result set for every query, or the numeric
+// one for fakeNumericQuery.
+
+type fakeDriver struct{}
+
+type fakeConn struct{}
+
+type fakeStmt struct {
+	query string
+}
+
+type fakeColumn struct {
+	name     string
+	dbType   string
+	scanType reflect.Type
+}
+
+type fakeRows struct {
+	pos     int
+	columns []fakeColumn
+	data    [][]driver.Value
+}
+
+const fakeNumericQuery = "SELECT amount FROM ledger"
+
+var fakeColumns = []fakeColumn{
+	{"id", "BIGINT", reflect.TypeOf(sql.NullInt64{})},
+	{"name", "VARCHAR", reflect.TypeOf(sql.NullString{})},
+	{"price", "DOUBLE", reflect.TypeOf(sql.NullFloat64{})},
+	{"created", "DATETIME", reflect.TypeOf(sql.NullTime{})},
+	{"meta", "JSON", reflect.TypeOf(sql.RawBytes{})},
+	{"raw", "BLOB", reflect.TypeOf(sql.RawBytes{})},
+	{"active", "BOOL", nil},
+}
+
+var fakeData = [][]driver.Value{
+	{int64(1), "kim", 9.5, time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC), []byte(`{"b": 1, "a": [true]}`), []byte{0xff, 0x00}, true},
+	{int64(2), nil, nil, nil, nil, []byte("text"), false},
+}
+
+var fakeNumericColumns = []fakeColumn{
+	{"amount", "DECIMAL", reflect.TypeOf(sql.RawBytes{})},
+	{"rate", "NUMERIC", nil},
+	{"code", "VARCHAR", reflect.TypeOf(sql.RawBytes{})},
+}
+
+var fakeNumericData = [][]driver.Value{
+	{[]byte("12345678901234567890.10"), "0.125", []byte("007")},
+	{nil, "NaN", nil},
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if m.query == fakeNumericQuery {
+		return &fakeRows{columns: fakeNumericColumns, data: fakeNumericData}, nil
+	}
+
+	return &fakeRows{columns: fakeColumns, data: fakeData}, nil
+}
+
+func (m *fakeRows) Columns() []string {
+	names := make([]string, len(m.columns))
+	for idx := range m.columns {
+		names[idx] = m.columns[idx].name
+	}
+	return names
+}
+
+func (m *fakeRows) Close() error {
+	return nil
+}
+
+func (m *fakeRows) Next(dest []driver.Value) error {
+	if m.pos >= len(m.data) {
+		return io.EOF
+	}
+
+	copy(dest, m.data[m.pos])
+	m.pos++
+	return nil
+}
+
+func (m *fakeRows) ColumnTypeDatabaseTypeName(idx int) string {
+	return m.columns[idx].dbType
+}
+
+func (m *fakeRows) ColumnTypeScanType(idx int) reflect.Type {
+	if m.columns[idx].scanType == nil {
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+	return m.columns[idx].scanType
+}
+
+func init() {
+	sql.Register("djson-fake", fakeDriver{})
+}
+
+func TestFromRows(t *testing.T) {
+	db, err := sql.Open("djson-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT * FROM items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	aJson, err := FromRows(rows, RowsOptions{
+		Rename: func(column string) string {
+			if column == "created" {
+				return "createdAt"
+			}
+			return column
+		},
+		ParseJSON: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"id":1,"name":"kim","price":9.5,"createdAt":"2024-05-01T09:30:00Z","meta":{"b":1,"a":[true]},"raw":"/wA=","active":true},` +
+		`{"id":2,"name":null,"price":null,"createdAt":null,"meta":null,"raw":"text","active":false}]`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if result := aJson.TypePath(`[0]["id"]`); result != "int" {
+		t.Errorf("Expected int, but got %s", result)
+	}
+
+	rows, err = db.Query("SELECT * FROM items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	bJson, err := FromRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := bJson.StringPath(`[0]["meta"]`); result != `{"b": 1, "a": [true]}` {
+		t.Errorf("Expected meta as text, but got %s", result)
+	}
+}
+
+func TestFromRowsNumeric(t *testing.T) {
+	db, err := sql.Open("djson-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(fakeNumericQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	aJson, err := FromRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"amount":12345678901234567890.10,"rate":0.125,"code":"007"},{"amount":null,"rate":"NaN","code":null}]`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	for _, each := range []string{`[0]["amount"]`, `[0]["rate"]`} {
+		if result := aJson.TypePath(each); result != "number" {
+			t.Errorf("Expected number for %s, but got %s", each, result)
+		}
+	}
+}
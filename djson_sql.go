@@ -0,0 +1,184 @@
+package djson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// JSON already has Scan() for array iteration, so it cannot implement
+// sql.Scanner itself. Use Scanner() as a Scan destination, or JSONColumn
+// and NullJSON as the type of a NOT NULL or nullable column (e.g. in
+// sqlboiler models). Value implements driver.Valuer and writes the JSON
+// text, like null.JSON.
+
+func (m *JSON) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	return encodeWith(m, EncodeOptions{DisableHTMLEscape: true})
+}
+
+// columnOptions reads JSON columns, for ScanFrom and FromRows alike.
+// Numbers become INT and FLOAT, as with Parse.
+
+var columnOptions = ParseOptions{Strict: true, Ordered: true}
+
+// ScanFrom replaces the value with the JSON document in src, which may be
+// []byte, string or nil (NULL).
+
+func (m *JSON) ScanFrom(src interface{}) error {
+	var doc []byte
+
+	switch t := src.(type) {
+	case nil:
+		*m = JSON{}
+		return nil
+	case []byte:
+		doc = t
+	case string:
+		doc = []byte(t)
+	default:
+		return errors.New("unsupported Scan type")
+	}
+
+	data, err := decodeDocument(doc, columnOptions)
+	if err != nil {
+		return err
+	}
+
+	*m = JSON{}
+	m.setDecoded(data)
+
+	return nil
+}
+
+type scanFunc func(src interface{}) error
+
+func (f scanFunc) Scan(src interface{}) error {
+	return f(src)
+}
+
+func (m *JSON) Scanner() sql.Scanner {
+	return scanFunc(m.ScanFrom)
+}
+
+// JSONColumn is a NOT NULL JSON column. Scanning NULL into it fails; use
+// NullJSON for a column that may be NULL.
+
+type JSONColumn struct {
+	JSON *JSON
+}
+
+func JSONColumnFrom(js *JSON) JSONColumn {
+	return JSONColumn{JSON: js}
+}
+
+func (m *JSONColumn) Scan(src interface{}) error {
+	if src == nil {
+		return errors.New("cannot scan NULL into JSONColumn")
+	}
+
+	js := New()
+	if err := js.ScanFrom(src); err != nil {
+		return err
+	}
+
+	m.JSON = js
+	return nil
+}
+
+func (m JSONColumn) Value() (driver.Value, error) {
+	if m.JSON == nil {
+		return []byte("null"), nil
+	}
+
+	return m.JSON.Value()
+}
+
+func (m JSONColumn) MarshalJSON() ([]byte, error) {
+	if m.JSON == nil {
+		return []byte("null"), nil
+	}
+
+	return m.JSON.MarshalJSON()
+}
+
+func (m *JSONColumn) UnmarshalJSON(b []byte) error {
+	js := New()
+	if err := js.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	m.JSON = js
+	return nil
+}
+
+// NullJSON is a JSON column that may be NULL, in the manner of null.JSON.
+
+type NullJSON struct {
+	JSON  *JSON
+	Valid bool
+}
+
+func NewNullJSON(js *JSON, valid bool) NullJSON {
+	return NullJSON{
+		JSON:  js,
+		Valid: valid,
+	}
+}
+
+func NullJSONFrom(js *JSON) NullJSON {
+	return NewNullJSON(js, js != nil)
+}
+
+func (m *NullJSON) Scan(src interface{}) error {
+	if src == nil {
+		m.JSON, m.Valid = nil, false
+		return nil
+	}
+
+	js := New()
+	if err := js.ScanFrom(src); err != nil {
+		return err
+	}
+
+	m.JSON, m.Valid = js, true
+	return nil
+}
+
+func (m NullJSON) Value() (driver.Value, error) {
+	if !m.Valid {
+		return nil, nil
+	}
+
+	return m.JSON.Value()
+}
+
+func (m NullJSON) IsZero() bool {
+	return !m.Valid
+}
+
+func (m NullJSON) MarshalJSON() ([]byte, error) {
+	if !m.Valid || m.JSON == nil {
+		return []byte("null"), nil
+	}
+
+	return m.JSON.MarshalJSON()
+}
+
+func (m *NullJSON) UnmarshalJSON(b []byte) error {
+	if isNullDocument(b) {
+		m.JSON, m.Valid = nil, false
+		return nil
+	}
+
+	js := New()
+	if err := js.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	m.JSON, m.Valid = js, true
+	return nil
+}
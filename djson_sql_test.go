@@ -0,0 +1,153 @@
+package djson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = (*JSON)(nil)
+	_ driver.Valuer = NullJSON{}
+	_ sql.Scanner   = (*NullJSON)(nil)
+	_ driver.Valuer = JSONColumn{}
+	_ sql.Scanner   = (*JSONColumn)(nil)
+)
+
+func TestJSONValueScan(t *testing.T) {
+	aJson := NewObject("name", "<kim>", "tags", Array{"a", "b"})
+
+	v, err := aJson.Value()
+	if err != nil || string(v.([]byte)) != `{"name":"<kim>","tags":["a","b"]}` {
+		t.Errorf("Expected JSON text, but got %v (%v)", v, err)
+	}
+
+	if v, _ := (*JSON)(nil).Value(); v != nil {
+		t.Errorf("Expected nil for a nil *JSON, but got %v", v)
+	}
+
+	bJson := New()
+	if err := bJson.Scanner().Scan([]byte(`{"b": 1, "a": [1, 2]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := bJson.ToString(); result != `{"b":1,"a":[1,2]}` {
+		t.Errorf("Expected {\"b\":1,\"a\":[1,2]}, but got %s", result)
+	}
+
+	// numbers are typed as with Parse
+	if err := bJson.ScanFrom(`{"a": 1, "b": 1.5}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, _ := bJson.Get("a"); !a.IsInt() || bJson.Type("b") != "float" {
+		t.Errorf("Expected int and float, but got %s and %s", bJson.Type("a"), bJson.Type("b"))
+	}
+
+	if err := bJson.ScanFrom(`[true]`); err != nil || !bJson.IsArray() {
+		t.Errorf("Expected rescanning into an array, but got %s (%v)", bJson.Type(), err)
+	}
+
+	if err := bJson.ScanFrom(nil); err != nil || !bJson.IsNull() {
+		t.Errorf("Expected null, but got %s", bJson.Type())
+	}
+
+	if err := bJson.ScanFrom(42); err == nil {
+		t.Errorf("Expected an int source to fail")
+	}
+
+	if err := bJson.ScanFrom(`{"a":`); err == nil {
+		t.Errorf("Expected malformed JSON to fail")
+	}
+}
+
+func TestNullJSON(t *testing.T) {
+	var nj NullJSON
+
+	if err := nj.Scan(nil); err != nil || nj.Valid {
+		t.Errorf("Expected invalid NullJSON, but got %v", nj.Valid)
+	}
+
+	if v, _ := nj.Value(); v != nil {
+		t.Errorf("Expected nil Value, but got %v", v)
+	}
+
+	if err := nj.Scan(`{"a": 1}`); err != nil || !nj.Valid || nj.JSON.Int("a") != 1 {
+		t.Errorf("Expected valid NullJSON, but got %v", err)
+	}
+
+	if result := nj.JSON.Type("a"); result != "int" {
+		t.Errorf("Expected int, but got %s", result)
+	}
+
+	if v, _ := nj.Value(); string(v.([]byte)) != `{"a":1}` {
+		t.Errorf("Expected {\"a\":1}, but got %s", v)
+	}
+
+	type row struct {
+		Doc  NullJSON `json:"doc"`
+		Note NullJSON `json:"note"`
+	}
+
+	b, err := json.Marshal(row{Doc: NullJSONFrom(NewArray(1)), Note: NullJSONFrom(nil)})
+	if err != nil || string(b) != `{"doc":[1],"note":null}` {
+		t.Errorf("Expected {\"doc\":[1],\"note\":null}, but got %s (%v)", b, err)
+	}
+
+	var r row
+	if err := json.Unmarshal([]byte(`{"doc": {"x": "y"}, "note": null}`), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Doc.Valid || r.Doc.JSON.String("x") != "y" || r.Note.Valid || !r.Note.IsZero() {
+		t.Errorf("Expected doc valid and note invalid, but got %v %v", r.Doc.Valid, r.Note.Valid)
+	}
+}
+
+func TestJSONColumn(t *testing.T) {
+	db, err := sql.Open("djson-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var rec struct {
+		ID   int64
+		Meta JSONColumn
+	}
+
+	var skip interface{}
+	err = db.QueryRow("SELECT * FROM items").Scan(&rec.ID, &skip, &skip, &skip, &rec.Meta, &skip, &skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := rec.Meta.JSON.ToString(); rec.ID != 1 || result != `{"b":1,"a":[true]}` {
+		t.Errorf("Expected {\"b\":1,\"a\":[true]}, but got %s", result)
+	}
+
+	if result := rec.Meta.JSON.Type("b"); result != "int" {
+		t.Errorf("Expected int, but got %s", result)
+	}
+
+	if v, _ := rec.Meta.Value(); string(v.([]byte)) != `{"b":1,"a":[true]}` {
+		t.Errorf("Expected {\"b\":1,\"a\":[true]}, but got %s", v)
+	}
+
+	var col JSONColumn
+	if err := col.Scan(nil); err == nil {
+		t.Errorf("Expected NULL to fail")
+	}
+
+	if v, _ := col.Value(); string(v.([]byte)) != "null" {
+		t.Errorf("Expected null, but got %s", v)
+	}
+
+	b, err := json.Marshal(struct {
+		Doc JSONColumn `json:"doc"`
+	}{JSONColumnFrom(NewArray(1))})
+	if err != nil || string(b) != `{"doc":[1]}` {
+		t.Errorf("Expected {\"doc\":[1]}, but got %s (%v)", b, err)
+	}
+}
@@ -0,0 +1,500 @@
+package djson
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+)
+
+// Decoder reads a stream of JSON values such as NDJSON or concatenated
+// JSON. Only the value being decoded is held in memory.
+
+type Decoder struct {
+	r      *bufio.Reader
+	offset int64
+	line   int
+	column int
+	buf    []byte
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r:      bufio.NewReader(r),
+		line:   1,
+		column: 1,
+	}
+}
+
+func (m *Decoder) readByte() (byte, error) {
+	c, err := m.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	m.offset++
+	if c == '\n' {
+		m.line++
+		m.column = 1
+	} else if utf8.RuneStart(c) {
+		m.column++
+	}
+
+	return c, nil
+}
+
+func (m *Decoder) unreadByte(c byte) {
+	_ = m.r.UnreadByte()
+
+	m.offset--
+	if utf8.RuneStart(c) {
+		m.column--
+	}
+}
+
+func (m *Decoder) peekByte() (byte, error) {
+	for {
+		c, err := m.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			m.offset++
+			if c == '\n' {
+				m.line++
+				m.column = 1
+			} else {
+				m.column++
+			}
+			continue
+		}
+
+		_ = m.r.UnreadByte()
+		return c, nil
+	}
+}
+
+// readValue consumes the next top-level value. The raw bytes are kept in
+// m.buf only when keep is true.
+
+func (m *Decoder) readValue(keep bool) error {
+	m.buf = m.buf[:0]
+
+	c, err := m.readByte()
+	if err != nil {
+		return err
+	}
+
+	if keep {
+		m.buf = append(m.buf, c)
+	}
+
+	switch c {
+	case '{', '[':
+		return m.readContainer(keep)
+	case '"':
+		return m.readString(keep)
+	}
+
+	for {
+		c, err := m.readByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			return nil
+		case ',', ':', '{', '}', '[', ']', '"':
+			m.unreadByte(c)
+			return nil
+		}
+
+		if keep {
+			m.buf = append(m.buf, c)
+		}
+	}
+}
+
+func (m *Decoder) readContainer(keep bool) error {
+	depth := 1
+
+	for depth > 0 {
+		c, err := m.readByte()
+		if err == io.EOF {
+			return nil // truncated, left to the parser to report
+		} else if err != nil {
+			return err
+		}
+
+		if keep {
+			m.buf = append(m.buf, c)
+		}
+
+		switch c {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case '"':
+			if err := m.readString(keep); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Decoder) readString(keep bool) error {
+	var escaped bool
+
+	for {
+		c, err := m.readByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if keep {
+			m.buf = append(m.buf, c)
+		}
+
+		if escaped {
+			escaped = false
+		} else if c == '\\' {
+			escaped = true
+		} else if c == '"' {
+			return nil
+		}
+	}
+}
+
+// Next returns the next value in the stream, or io.EOF when the stream is
+// exhausted. A malformed value is reported as *ParseError positioned in
+// the whole stream; decoding can continue with the following value.
+
+func (m *Decoder) Next() (*JSON, error) {
+	if _, err := m.peekByte(); err != nil {
+		return nil, err
+	}
+
+	offset, line, column := m.offset, m.line, m.column
+
+	if err := m.readValue(true); err != nil {
+		return nil, err
+	}
+
+	js, err := New().ParseBytes(m.buf)
+	if err != nil {
+		return nil, shiftParseError(err, offset, line, column)
+	}
+
+	return js, nil
+}
+
+func shiftParseError(err error, offset int64, line, column int) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return err
+	}
+
+	if pe.Line == 1 {
+		pe.Column += column - 1
+	}
+
+	pe.Line += line - 1
+	pe.Offset += offset
+
+	return pe
+}
+
+// Encoder writes values as JSON Lines, one value per line.
+
+type Encoder struct {
+	w io.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+func (m *Encoder) Encode(js *JSON) error {
+	var line []byte
+
+	if js == nil {
+		line = []byte("null")
+	} else if js._Type == STRING {
+		var err error
+		if line, err = json.Marshal(js._String); err != nil {
+			return err
+		}
+	} else {
+		line = []byte(js.ToString())
+	}
+
+	line = append(line, '\n')
+
+	_, err := m.w.Write(line)
+	return err
+}
+
+// peekMore skips whitespace before a value that must be present.
+
+func (m *Decoder) peekMore() error {
+	_, err := m.peekByte()
+	if err == io.EOF {
+		return m.fail("unexpected end of input")
+	}
+
+	return err
+}
+
+func (m *Decoder) fail(msg string) error {
+	return &ParseError{
+		Msg:    msg,
+		Offset: m.offset,
+		Line:   m.line,
+		Column: m.column,
+	}
+}
+
+func (m *Decoder) expectByte(want byte) error {
+	c, err := m.peekByte()
+	if err == io.EOF {
+		return m.fail("unexpected end of input")
+	} else if err != nil {
+		return err
+	}
+
+	if c != want {
+		return m.fail("invalid character " + strconv.QuoteRune(rune(c)) + " looking for " + strconv.QuoteRune(rune(want)))
+	}
+
+	_, _ = m.readByte()
+	return nil
+}
+
+// nextDelim consumes the separator after a container member and reports
+// whether another member follows.
+
+func (m *Decoder) nextDelim(closer byte) (bool, error) {
+	c, err := m.peekByte()
+	if err == io.EOF {
+		return false, m.fail("unexpected end of input")
+	} else if err != nil {
+		return false, err
+	}
+
+	if c != ',' && c != closer {
+		return false, m.fail("invalid character " + strconv.QuoteRune(rune(c)) + " after container member")
+	}
+
+	_, _ = m.readByte()
+	return c == ',', nil
+}
+
+// isEmptyContainer consumes the closer when the container that was just
+// opened has no members.
+
+func (m *Decoder) isEmptyContainer(closer byte) (bool, error) {
+	c, err := m.peekByte()
+	if err == io.EOF {
+		return false, m.fail("unexpected end of input")
+	} else if err != nil {
+		return false, err
+	}
+
+	if c == closer {
+		_, _ = m.readByte()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (m *Decoder) readKey() (string, error) {
+	if c, err := m.peekByte(); err != nil || c != '"' {
+		if err == nil || err == io.EOF {
+			return "", m.fail("looking for beginning of object key")
+		}
+		return "", err
+	}
+
+	if err := m.readValue(true); err != nil {
+		return "", err
+	}
+
+	var key string
+	if err := json.Unmarshal(m.buf, &key); err != nil {
+		return "", m.fail("invalid object key")
+	}
+
+	if err := m.expectByte(':'); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (m *Decoder) seekKey(want string) error {
+	if err := m.expectByte('{'); err != nil {
+		return err
+	}
+
+	if empty, err := m.isEmptyContainer('}'); err != nil {
+		return err
+	} else if empty {
+		return m.fail("no such key " + want)
+	}
+
+	for {
+		key, err := m.readKey()
+		if err != nil {
+			return err
+		}
+
+		if key == want {
+			return m.peekMore()
+		}
+
+		if err := m.peekMore(); err != nil {
+			return err
+		}
+
+		if err := m.readValue(false); err != nil {
+			return err
+		}
+
+		more, err := m.nextDelim('}')
+		if err != nil {
+			return err
+		}
+
+		if !more {
+			return m.fail("no such key " + want)
+		}
+	}
+}
+
+func (m *Decoder) seekIndex(want int) error {
+	if err := m.expectByte('['); err != nil {
+		return err
+	}
+
+	if empty, err := m.isEmptyContainer(']'); err != nil {
+		return err
+	} else if empty {
+		return m.fail("no such index " + strconv.Itoa(want))
+	}
+
+	for idx := 0; idx < want; idx++ {
+		if err := m.peekMore(); err != nil {
+			return err
+		}
+
+		if err := m.readValue(false); err != nil {
+			return err
+		}
+
+		more, err := m.nextDelim(']')
+		if err != nil {
+			return err
+		}
+
+		if !more {
+			return m.fail("no such index " + strconv.Itoa(want))
+		}
+	}
+
+	return m.peekMore()
+}
+
+// ScanArrayPath walks the next value in the stream down to the array at
+// path (as in PathTokenizer, "" for the value itself) and calls fn with each of
+// its elements in turn, holding only one element in memory at a time.
+// A non-nil error from fn stops the scan and is returned. Either way the
+// rest of the value is skipped, so the next call reads the following value.
+
+func (m *Decoder) ScanArrayPath(path string, fn func(idx int, js *JSON) error) error {
+	tokens, err := tokenizePath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		switch tkey := token.(type) {
+		case string:
+			err = m.seekKey(tkey)
+		case int:
+			err = m.seekIndex(tkey)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := m.expectByte('['); err != nil {
+		return err
+	}
+
+	if empty, err := m.isEmptyContainer(']'); err != nil {
+		return err
+	} else if empty {
+		return m.skipOpen(len(tokens), nil)
+	}
+
+	for idx := 0; ; idx++ {
+		if err := m.peekMore(); err != nil {
+			return err
+		}
+
+		offset, line, column := m.offset, m.line, m.column
+
+		if err := m.readValue(true); err != nil {
+			return err
+		}
+
+		js, err := New().ParseBytes(m.buf)
+		if err != nil {
+			return shiftParseError(err, offset, line, column)
+		}
+
+		if err := fn(idx, js); err != nil {
+			return m.skipOpen(len(tokens)+1, err)
+		}
+
+		more, err := m.nextDelim(']')
+		if err != nil {
+			return err
+		}
+
+		if !more {
+			return m.skipOpen(len(tokens), nil)
+		}
+	}
+}
+
+// skipOpen consumes the rest of the n containers that are still open and
+// then returns err.
+
+func (m *Decoder) skipOpen(n int, err error) error {
+	for idx := 0; idx < n; idx++ {
+		if rerr := m.readContainer(false); rerr != nil {
+			return rerr
+		}
+	}
+
+	return err
+}
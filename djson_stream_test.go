@@ -0,0 +1,218 @@
+package djson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderNDJSON(t *testing.T) {
+	stream := "{\"name\":\"kim\",\"tags\":[\"a\",\"b\"]}\n" +
+		"[1,2,3]\n" +
+		"\n" +
+		"\"text\"\n" +
+		"42\n" +
+		"{\"note\":\"brace } inside\"}{\"concat\":true} null"
+
+	dec := NewDecoder(strings.NewReader(stream))
+
+	expected := []string{
+		`{"name":"kim","tags":["a","b"]}`,
+		`[1,2,3]`,
+		`text`,
+		`42`,
+		`{"note":"brace } inside"}`,
+		`{"concat":true}`,
+		`null`,
+	}
+
+	for _, each := range expected {
+		js, err := dec.Next()
+		if err != nil {
+			t.Fatalf("Expected %s, but got %v", each, err)
+		}
+
+		if result := js.ToString(); result != each {
+			t.Errorf("Expected %s, but got %s", each, result)
+		}
+	}
+
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, but got %v", err)
+	}
+}
+
+func TestDecoderError(t *testing.T) {
+	stream := "{\"a\":1}\n{\"b\":2}\n  {\"c\":,}\n{\"d\":4}\n"
+
+	dec := NewDecoder(strings.NewReader(stream))
+
+	for idx := 0; idx < 2; idx++ {
+		if _, err := dec.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := dec.Next()
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *ParseError, but got %v", err)
+	}
+
+	if pe.Line != 3 || pe.Column != 8 || pe.Offset != 23 {
+		t.Errorf("Expected line 3 column 8 offset 23, but got %d %d %d", pe.Line, pe.Column, pe.Offset)
+	}
+
+	js, err := dec.Next()
+	if err != nil || js.Int("d") != 4 {
+		t.Errorf("Expected decoding to continue after a bad value, but got %v", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+	_ = enc.Encode(NewObject("name", "kim"))
+	_ = enc.Encode(NewArray(1, 2))
+	_ = enc.Encode(NewString("line\nbreak"))
+	_ = enc.Encode(New())
+
+	expected := "{\"name\":\"kim\"}\n[1,2]\n\"line\\nbreak\"\nnull\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, buf.String())
+	}
+
+	dec := NewDecoder(&buf)
+	count := 0
+	for {
+		if _, err := dec.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+
+	if count != 4 {
+		t.Errorf("Expected 4 values, but got %d", count)
+	}
+}
+
+func TestScanArrayPath(t *testing.T) {
+	doc := `{
+		"meta": {"count": 3, "skip": [{"x": "]"}]},
+		"data": {
+			"total": 3,
+			"items": [
+				{"sku": "a-1", "qty": 1},
+				{"sku": "b-2", "qty": 2},
+				{"sku": "c-3", "qty": 3}
+			]
+		}
+	}`
+
+	skus := make([]string, 0)
+	var sum int64
+
+	err := NewDecoder(strings.NewReader(doc)).ScanArrayPath(`["data"]["items"]`, func(idx int, js *JSON) error {
+		skus = append(skus, js.String("sku"))
+		sum += js.Int("qty")
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(skus, ",") != "a-1,b-2,c-3" || sum != 6 {
+		t.Errorf("Expected a-1,b-2,c-3 and 6, but got %v and %d", skus, sum)
+	}
+
+	var count int
+	err = NewDecoder(strings.NewReader(`[[0], [1, 2, 3]]`)).ScanArrayPath(`[1]`, func(idx int, js *JSON) error {
+		count++
+		return nil
+	})
+
+	if err != nil || count != 3 {
+		t.Errorf("Expected 3 elements, but got %d (%v)", count, err)
+	}
+
+	stop := errors.New("stop")
+	err = NewDecoder(strings.NewReader(`[1, 2, 3]`)).ScanArrayPath("", func(idx int, js *JSON) error {
+		if idx == 1 {
+			return stop
+		}
+		return nil
+	})
+
+	if err != stop {
+		t.Errorf("Expected stop, but got %v", err)
+	}
+
+	err = NewDecoder(strings.NewReader(doc)).ScanArrayPath(`["data"]["missing"]`, func(idx int, js *JSON) error {
+		return nil
+	})
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("Expected *ParseError, but got %v", err)
+	}
+
+	err = NewDecoder(strings.NewReader(`{"items": [1, 2`)).ScanArrayPath(`["items"]`, func(idx int, js *JSON) error {
+		return nil
+	})
+
+	if !errors.As(err, &pe) || pe.Msg != "unexpected end of input" {
+		t.Errorf("Expected unexpected end of input, but got %v", err)
+	}
+
+	err = NewDecoder(strings.NewReader(`x`)).ScanArrayPath(`a[0]junk`, func(idx int, js *JSON) error {
+		return nil
+	})
+
+	if !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Expected ErrInvalidPath, but got %v", err)
+	}
+}
+
+func TestScanArrayPathStream(t *testing.T) {
+	stream := `{"items": [1, 2], "after": {"x": "]"}}
+{"items": [], "after": 1}
+{"items": [3, 4, 5], "after": [[]]}
+{"n": 6}`
+
+	dec := NewDecoder(strings.NewReader(stream))
+	stop := errors.New("stop")
+	var sum int64
+
+	for idx := 0; idx < 3; idx++ {
+		err := dec.ScanArrayPath(`items`, func(i int, js *JSON) error {
+			sum += js.Int()
+			if js.Int() == 4 {
+				return stop
+			}
+			return nil
+		})
+
+		if err != nil && err != stop {
+			t.Fatal(err)
+		}
+	}
+
+	if sum != 10 {
+		t.Errorf("Expected 10, but got %d", sum)
+	}
+
+	if js, err := dec.Next(); err != nil || js.Int("n") != 6 {
+		t.Errorf("Expected the fourth document, but got %v", err)
+	}
+
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, but got %v", err)
+	}
+}
@@ -43,10 +43,18 @@ func (m *JSON) IsInt(key ...interface{}) bool {
 
 func (m *JSON) IsNumeric(key ...interface{}) bool {
 	if IsEmptyArg(key) {
-		return m._Type == FLOAT || m._Type == INT
+		return m._Type == FLOAT || m._Type == INT || m._Type == NUMBER
 	}
 
-	return m.isSameType(key[0], "int") || m.isSameType(key[0], "float")
+	return m.isSameType(key[0], "int") || m.isSameType(key[0], "float") || m.isSameType(key[0], "number")
+}
+
+func (m *JSON) IsNumber(key ...interface{}) bool {
+	if IsEmptyArg(key) {
+		return m._Type == NUMBER
+	}
+
+	return m.isSameType(key[0], "number")
 }
 
 func (m *JSON) IsFloat(key ...interface{}) bool {
@@ -106,6 +114,8 @@ func (m *JSON) Type(key ...interface{}) string {
 			return "float"
 		case BOOL:
 			return "bool"
+		case NUMBER:
+			return "number"
 		}
 
 		return ""
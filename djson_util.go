@@ -571,6 +571,8 @@ func (m *JSON) Equal(t *JSON) bool {
 		return m._Int == t._Int
 	case FLOAT:
 		return m._Float == t._Float
+	case NUMBER:
+		return m._Number == t._Number
 	case STRING:
 		return m._String == t._String
 	case OBJECT:
@@ -593,6 +595,8 @@ func (m *JSON) Clone() *JSON {
 		t._Int = m._Int
 	case FLOAT:
 		t._Float = m._Float
+	case NUMBER:
+		t._Number = m._Number
 	case STRING:
 		t._String = m._String
 	case OBJECT:
@@ -622,11 +626,7 @@ func (m *JSON) GetKeys(k ...interface{}) []string {
 			return rk
 		}
 
-		for k := range m._Object.Map {
-			rk = append(rk, k)
-		}
-
-		return rk
+		return m._Object.Keys()
 	}
 
 	if t, ok := m.Object(k[0]); ok {
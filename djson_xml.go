@@ -0,0 +1,343 @@
+package djson
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// XML elements map onto djson values as follows:
+//
+//	<a/>                      "a": null
+//	<a>text</a>               "a": "text"
+//	<a id="1">text</a>        "a": {"@id": "1", "#text": "text"}
+//	<a><b>1</b><b>2</b></a>   "a": {"b": ["1", "2"]}
+//
+// The document becomes an object with the root element as its only key.
+// Text is trimmed and stays a string; attributes and child elements keep
+// document order. Names are local names: namespace prefixes and xmlns
+// declarations are dropped.
+
+// XMLOptions controls ParseXML and ToXML.
+// AttrPrefix marks attribute keys ("@" if empty) and TextKey holds the text
+// of elements that also have attributes or children ("#text" if empty).
+// ForceArray lists element paths from the root, such as
+// "Envelope/Body/Item", that always become arrays, even with one element.
+// Root names the root element when ToXML writes a value that is not an
+// object with a single key, and Indent indents the output of ToXML.
+
+type XMLOptions struct {
+	AttrPrefix string
+	TextKey    string
+	ForceArray []string
+	Root       string
+	Indent     string
+}
+
+func (o XMLOptions) attrPrefix() string {
+	if o.AttrPrefix == "" {
+		return "@"
+	}
+	return o.AttrPrefix
+}
+
+func (o XMLOptions) textKey() string {
+	if o.TextKey == "" {
+		return "#text"
+	}
+	return o.TextKey
+}
+
+type xmlFrame struct {
+	name string
+	path string
+	obj  *DO
+	text strings.Builder
+}
+
+// ParseXML reads one XML document from r.
+
+func (m *JSON) ParseXML(r io.Reader, opts XMLOptions) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	forced := make(map[string]bool, len(opts.ForceArray))
+	for _, each := range opts.ForceArray {
+		forced[strings.Trim(each, "/")] = true
+	}
+
+	d := xml.NewDecoder(r)
+
+	root := NewOrderedDO()
+	var stack []*xmlFrame
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && len(root.Map) > 0 {
+				return m, errors.New("XML document has more than one root element")
+			}
+
+			frame := &xmlFrame{
+				name: t.Name.Local,
+				path: t.Name.Local,
+				obj:  NewOrderedDO(),
+			}
+			if len(stack) > 0 {
+				frame.path = stack[len(stack)-1].path + "/" + t.Name.Local
+			}
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				frame.obj.set(opts.attrPrefix()+attr.Name.Local, attr.Value)
+			}
+
+			stack = append(stack, frame)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var v interface{}
+			text := strings.TrimSpace(frame.text.String())
+
+			if len(frame.obj.Map) == 0 {
+				if text != "" {
+					v = text
+				}
+			} else {
+				if text != "" {
+					frame.obj.set(opts.textKey(), text)
+				}
+				v = frame.obj
+			}
+
+			parent := root
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].obj
+			}
+
+			if prev, ok := parent.Map[frame.name]; ok {
+				if arr, isArr := prev.(*DA); isArr {
+					arr.Element = append(arr.Element, v)
+				} else {
+					parent.set(frame.name, &DA{Element: []interface{}{prev, v}})
+				}
+			} else if forced[frame.path] {
+				parent.set(frame.name, &DA{Element: []interface{}{v}})
+			} else {
+				parent.set(frame.name, v)
+			}
+		}
+	}
+
+	if len(root.Map) == 0 {
+		return m, errors.New("XML document has no root element")
+	}
+
+	return m.setDecoded(root), nil
+}
+
+// ToXML writes the value as an XML document using the mapping of ParseXML.
+// Arrays become repeated elements, so an array directly inside an array
+// cannot be written.
+
+func (m *JSON) ToXML(opts XMLOptions) ([]byte, error) {
+	v, err := normalizeValue(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	e := &xmlEncoder{
+		enc:  xml.NewEncoder(&buf),
+		opts: opts,
+	}
+	e.enc.Indent("", opts.Indent)
+
+	name := opts.Root
+	if obj, ok := v.(*DO); ok && len(obj.Map) == 1 && name == "" {
+		for key := range obj.Map {
+			name, v = key, obj.Map[key]
+		}
+	}
+
+	if v, err = normalizeValue(v); err != nil {
+		return nil, err
+	}
+
+	if _, isArr := v.(*DA); isArr || name == "" {
+		return nil, errors.New("XML needs a single root element")
+	}
+
+	if err := e.encodeElement(name, v); err != nil {
+		return nil, err
+	}
+
+	if err := e.enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type xmlEncoder struct {
+	enc  *xml.Encoder
+	opts XMLOptions
+}
+
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for idx, r := range s {
+		if unicode.IsLetter(r) || r == '_' || r == ':' {
+			continue
+		}
+		if idx > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
+
+func (e *xmlEncoder) encodeElement(name string, v interface{}) error {
+	if !isXMLName(name) {
+		return errors.New("invalid XML name " + strconv.Quote(name))
+	}
+
+	v, err := normalizeValue(v)
+	if err != nil {
+		return err
+	}
+
+	if arr, ok := v.(*DA); ok {
+		for idx := range arr.Element {
+			item, err := normalizeValue(arr.Element[idx])
+			if err != nil {
+				return err
+			}
+			if _, nested := item.(*DA); nested {
+				return errors.New("nested array in element " + strconv.Quote(name))
+			}
+			if err := e.encodeElement(name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	obj, isObj := v.(*DO)
+	if !isObj {
+		if err := e.enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			text, err := xmlText(v)
+			if err != nil {
+				return err
+			}
+			if err := e.enc.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		return e.enc.EncodeToken(start.End())
+	}
+
+	keys := obj.Keys()
+	prefix := e.opts.attrPrefix()
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		attr := strings.TrimPrefix(key, prefix)
+		if !isXMLName(attr) {
+			return errors.New("invalid XML name " + strconv.Quote(attr))
+		}
+
+		text, err := xmlText(obj.Map[key])
+		if err != nil {
+			return err
+		}
+
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: attr}, Value: text})
+	}
+
+	if err := e.enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		if key == e.opts.textKey() {
+			text, err := xmlText(obj.Map[key])
+			if err != nil {
+				return err
+			}
+			if err := e.enc.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.encodeElement(key, obj.Map[key]); err != nil {
+			return err
+		}
+	}
+
+	return e.enc.EncodeToken(start.End())
+}
+
+// xmlText formats a scalar as text. Numbers use the JSON form.
+
+func xmlText(v interface{}) (string, error) {
+	v, err := normalizeValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case *DO, *DA:
+		return "", errors.New("object or array used as XML text")
+	}
+
+	b, err := encodeWith(v, EncodeOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,107 @@
+package djson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseXML(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <Result code="0" lang="ko">
+      <Item id="1">first</Item>
+      <Item id="2"><![CDATA[a < b]]></Item>
+      <Owner>kim</Owner>
+      <Empty/>
+      <Note>
+        mixed <b>bold</b> text
+      </Note>
+    </Result>
+  </soap:Body>
+</soap:Envelope>`
+
+	aJson, err := New().ParseXML(strings.NewReader(doc), XMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"Envelope":{"Body":{"Result":{"@code":"0","@lang":"ko",` +
+		`"Item":[{"@id":"1","#text":"first"},{"@id":"2","#text":"a < b"}],` +
+		`"Owner":"kim","Empty":null,"Note":{"b":"bold","#text":"mixed  text"}}}}}`
+	if result := aJson.ToStringWith(EncodeOptions{DisableHTMLEscape: true}); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	bJson, err := New().ParseXML(strings.NewReader(doc), XMLOptions{
+		AttrPrefix: "-",
+		TextKey:    "value",
+		ForceArray: []string{"Envelope/Body/Result/Owner"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := bJson.StringPath(`["Envelope"]["Body"]["Result"]["Owner"][0]`); result != "kim" {
+		t.Errorf("Expected kim, but got %s", result)
+	}
+
+	if result := bJson.StringPath(`["Envelope"]["Body"]["Result"]["Item"][1]["value"]`); result != "a < b" {
+		t.Errorf("Expected a < b, but got %s", result)
+	}
+
+	if result := bJson.StringPath(`["Envelope"]["Body"]["Result"]["-code"]`); result != "0" {
+		t.Errorf("Expected 0, but got %s", result)
+	}
+
+	for _, each := range []string{"", "<a>", "<a/><b/>", "<a></b>"} {
+		if _, err := New().ParseXML(strings.NewReader(each), XMLOptions{}); err == nil {
+			t.Errorf("Expected error for %q", each)
+		}
+	}
+}
+
+func TestToXML(t *testing.T) {
+	aJson := New().Parse(`{"order":{"@id":7,"@paid":true,"item":[{"@sku":"a1","#text":"pen"},"ink"],"note":"<fragile> & dry","empty":null,"price":1.5}}`)
+
+	b, err := aJson.ToXML(XMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<order id="7" paid="true"><empty></empty><item sku="a1">pen</item><item>ink</item>` +
+		`<note>&lt;fragile&gt; &amp; dry</note><price>1.5</price></order>`
+	if string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+
+	bJson, err := New().ParseXML(strings.NewReader(string(b)), XMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := bJson.StringPath(`["order"]["note"]`); result != "<fragile> & dry" {
+		t.Errorf("Expected <fragile> & dry, but got %s", result)
+	}
+
+	b, err = NewArray("a", "b").ToXML(XMLOptions{Root: "list", Indent: "  "})
+	if err == nil {
+		t.Errorf("Expected error for an array root, but got %s", b)
+	}
+
+	b, err = New().Parse(`{"x":["a","b"]}`).ToXML(XMLOptions{Root: "list", Indent: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "<list>\n  <x>a</x>\n  <x>b</x>\n</list>"
+	if string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+
+	for _, each := range []string{`{"a":1,"b":2}`, `{"a":[[1]]}`, `{"1a":1}`, `{"a":{"@b":{}}}`} {
+		if _, err := New().Parse(each).ToXML(XMLOptions{}); err == nil {
+			t.Errorf("Expected error for %s", each)
+		}
+	}
+}
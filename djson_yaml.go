@@ -0,0 +1,397 @@
+package djson
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// YAML scalars are resolved with the YAML 1.2 core schema: null and ~
+// become NULL, true / false BOOL, decimal, 0x and 0o integers INT and
+// other numbers FLOAT. JSON has no infinity or NaN, so .inf, -.inf and
+// .nan become NULL as well. Quoted and block scalars are always STRING.
+// Mappings become ordered objects, and aliases are expanded into copies of
+// their anchored node. Unlike YAML 1.1, yes / no and on / off stay strings.
+
+var ErrYAMLMultipleDocuments = errors.New("YAML stream has more than one document")
+
+// ParseYAML parses a YAML document. An empty document gives NULL, and a
+// stream with several documents fails with ErrYAMLMultipleDocuments; use
+// ParseYAMLAll for those.
+
+func (m *JSON) ParseYAML(doc []byte) (*JSON, error) {
+	if m._Type != NULL {
+		return m, errors.New("not Null")
+	}
+
+	docs, err := newYAMLParser(doc).parseStream()
+	if err != nil {
+		return m, err
+	}
+
+	switch len(docs) {
+	case 0:
+		return m, nil
+	case 1:
+		return m.setDecoded(docs[0]), nil
+	}
+
+	return m, ErrYAMLMultipleDocuments
+}
+
+// ParseYAMLAll parses every document of a YAML stream separated by ---.
+
+func ParseYAMLAll(doc []byte) ([]*JSON, error) {
+	docs, err := newYAMLParser(doc).parseStream()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*JSON, len(docs))
+	for idx := range docs {
+		result[idx] = New().setDecoded(docs[idx])
+	}
+
+	return result, nil
+}
+
+// ToYAML writes the value as a block style YAML document. Strings that
+// would read back as another type are quoted, and FLOAT values always
+// have a fraction or exponent, so ParseYAML gives back the same types.
+
+func (m *JSON) ToYAML() ([]byte, error) {
+	e := &yamlEncoder{}
+	if err := e.encodeDocument(m); err != nil {
+		return nil, err
+	}
+
+	return e.buf, nil
+}
+
+// ToYAMLAll writes docs as one YAML stream, separated by ---.
+
+func ToYAMLAll(docs ...*JSON) ([]byte, error) {
+	e := &yamlEncoder{}
+
+	for idx := range docs {
+		if idx > 0 {
+			e.buf = append(e.buf, "---\n"...)
+		}
+		if err := e.encodeDocument(docs[idx]); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.buf, nil
+}
+
+type yamlEncoder struct {
+	buf []byte
+}
+
+func (e *yamlEncoder) encodeDocument(v interface{}) error {
+	v, err := normalizeValue(v)
+	if err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case *DO:
+		if len(t.Map) > 0 {
+			return e.encodeObject(t, 0, false)
+		}
+	case *DA:
+		if len(t.Element) > 0 {
+			return e.encodeArray(t, 0, false)
+		}
+	}
+
+	if err := e.encodeScalar(v, 2); err != nil {
+		return err
+	}
+
+	e.buf = append(e.buf, '\n')
+	return nil
+}
+
+// normalizeValue turns the values a DO or DA may hold into *DO, *DA or a
+// scalar that encodeScalar knows.
+
+func normalizeValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case *DO:
+		if t == nil {
+			return nil, nil
+		}
+	case DO:
+		return &t, nil
+	case *DA:
+		if t == nil {
+			return nil, nil
+		}
+	case DA:
+		return &t, nil
+	case *JSON:
+		if t == nil {
+			return nil, nil
+		}
+		return normalizeValue(t.Interface())
+	case JSON:
+		return normalizeValue(t.Interface())
+	case map[string]interface{}:
+		return MapToObject(t), nil
+	case Object:
+		return MapToObject(t), nil
+	case []interface{}:
+		return SliceToArray(t), nil
+	case Array:
+		return SliceToArray(t), nil
+	case nil, string, bool, float32, float64, Number,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+	default:
+		b, err := encodeWith(t, EncodeOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return decodeDocument(b, ParseOptions{Strict: true, Ordered: true})
+	}
+
+	return v, nil
+}
+
+func (e *yamlEncoder) pad(indent int) {
+	for idx := 0; idx < indent; idx++ {
+		e.buf = append(e.buf, ' ')
+	}
+}
+
+// encodeObject writes the entries of obj at indent. With inline the first
+// entry continues the current line, as after "- ".
+
+func (e *yamlEncoder) encodeObject(obj *DO, indent int, inline bool) error {
+	for idx, key := range obj.Keys() {
+		if idx > 0 || !inline {
+			e.pad(indent)
+		}
+
+		e.encodeString(key)
+		e.buf = append(e.buf, ':')
+
+		if err := e.encodeEntry(obj.Map[key], indent+2); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *yamlEncoder) encodeArray(arr *DA, indent int, inline bool) error {
+	for idx := range arr.Element {
+		if idx > 0 || !inline {
+			e.pad(indent)
+		}
+
+		e.buf = append(e.buf, '-')
+
+		v, err := normalizeValue(arr.Element[idx])
+		if err != nil {
+			return err
+		}
+
+		switch t := v.(type) {
+		case *DO:
+			if len(t.Map) > 0 {
+				e.buf = append(e.buf, ' ')
+				if err := e.encodeObject(t, indent+2, true); err != nil {
+					return err
+				}
+				continue
+			}
+		case *DA:
+			if len(t.Element) > 0 {
+				e.buf = append(e.buf, ' ')
+				if err := e.encodeArray(t, indent+2, true); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err := e.encodeEntry(v, indent+2); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeEntry writes the value after "key:" or "-". Non-empty collections
+// go on the following lines at childIndent.
+
+func (e *yamlEncoder) encodeEntry(v interface{}, childIndent int) error {
+	v, err := normalizeValue(v)
+	if err != nil {
+		return err
+	}
+
+	switch t := v.(type) {
+	case *DO:
+		if len(t.Map) > 0 {
+			e.buf = append(e.buf, '\n')
+			return e.encodeObject(t, childIndent, false)
+		}
+	case *DA:
+		if len(t.Element) > 0 {
+			e.buf = append(e.buf, '\n')
+			return e.encodeArray(t, childIndent, false)
+		}
+	}
+
+	e.buf = append(e.buf, ' ')
+	if err := e.encodeScalar(v, childIndent); err != nil {
+		return err
+	}
+
+	e.buf = append(e.buf, '\n')
+	return nil
+}
+
+func (e *yamlEncoder) encodeScalar(v interface{}, indent int) error {
+	switch t := v.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case *DO:
+		e.buf = append(e.buf, "{}"...)
+	case *DA:
+		e.buf = append(e.buf, "[]"...)
+	case string:
+		if !e.encodeBlockString(t, indent) {
+			e.encodeString(t)
+		}
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, t)
+	case float32:
+		e.encodeFloat(float64(t), 32)
+	case float64:
+		e.encodeFloat(t, 64)
+	case Number:
+		if !t.IsValid() {
+			return errors.New("invalid Number " + strconv.Quote(string(t)))
+		}
+		e.buf = append(e.buf, t...)
+	default:
+		if u, ok := t.(uint64); ok {
+			e.buf = strconv.AppendUint(e.buf, u, 10)
+			break
+		}
+		i, ok := getIntBase(t)
+		if !ok {
+			return errors.New("unsupported type")
+		}
+		e.buf = strconv.AppendInt(e.buf, i, 10)
+	}
+
+	return nil
+}
+
+func (e *yamlEncoder) encodeFloat(f float64, bits int) {
+	switch {
+	case math.IsNaN(f):
+		e.buf = append(e.buf, ".nan"...)
+	case math.IsInf(f, 1):
+		e.buf = append(e.buf, ".inf"...)
+	case math.IsInf(f, -1):
+		e.buf = append(e.buf, "-.inf"...)
+	default:
+		je := newEncoder()
+		je.encodeFloat(f, bits)
+		e.buf = append(e.buf, je.buf...)
+		if !strings.ContainsAny(string(je.buf), ".e") {
+			e.buf = append(e.buf, ".0"...)
+		}
+	}
+}
+
+// encodeString writes s plain when it reads back as the same string, and
+// double-quoted otherwise. JSON string escapes are valid in YAML.
+
+func (e *yamlEncoder) encodeString(s string) {
+	if isYAMLPlainSafe(s) {
+		e.buf = append(e.buf, s...)
+		return
+	}
+
+	je := newEncoderWith(EncodeOptions{DisableHTMLEscape: true})
+	je.encodeString(s)
+	e.buf = append(e.buf, je.buf...)
+}
+
+// encodeBlockString writes a multi-line string as a literal block scalar,
+// keeping its trailing line breaks with the chomping indicator.
+
+func (e *yamlEncoder) encodeBlockString(s string, indent int) bool {
+	if !strings.Contains(strings.TrimRight(s, "\n"), "\n") || s[0] == ' ' || s[0] == '\t' || s[0] == '\n' {
+		return false
+	}
+
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f || r == utf8.RuneError || r == 0x85 || r == 0xfeff ||
+			r == 0x2028 || r == 0x2029 {
+			return false
+		}
+	}
+
+	body := s
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		e.buf = append(e.buf, "|-"...)
+	case strings.HasSuffix(s, "\n\n"):
+		e.buf = append(e.buf, "|+"...)
+		body = s[:len(s)-1]
+	default:
+		e.buf = append(e.buf, '|')
+		body = s[:len(s)-1]
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		e.buf = append(e.buf, '\n')
+		if line != "" {
+			e.pad(indent)
+			e.buf = append(e.buf, line...)
+		}
+	}
+
+	return true
+}
+
+func isYAMLPlainSafe(s string) bool {
+	if s == "" || s[0] == ' ' || s[len(s)-1] == ' ' || s[len(s)-1] == ':' {
+		return false
+	}
+
+	if _, ok := resolveYAMLScalar(s).(string); !ok {
+		return false
+	}
+
+	// YAML 1.1 readers, still common for Kubernetes, take these as booleans
+	switch strings.ToLower(s) {
+	case "y", "n", "yes", "no", "on", "off":
+		return false
+	}
+
+	if strings.IndexByte("-?:,[]{}#&*!|>'\"%@`\t", s[0]) >= 0 ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasPrefix(s, "---") || strings.HasPrefix(s, "...") {
+		return false
+	}
+
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError || r == 0x85 || r == 0xfeff || r == 0x2028 || r == 0x2029 {
+			return false
+		}
+	}
+
+	return true
+}
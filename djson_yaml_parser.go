@@ -0,0 +1,1113 @@
+package djson
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// yamlParser reads the YAML subset used by configuration files: block and
+// flow collections, plain, quoted and block scalars, comments, anchors and
+// aliases, merge keys and multi-document streams. Complex (?) keys and
+// non-scalar keys are not supported.
+
+type yamlParser struct {
+	data      []byte
+	pos       int
+	lineStart int
+	anchors   map[string]interface{}
+	sizes     map[string]int
+	expanded  int
+}
+
+// yamlMaxExpansion bounds the number of nodes copied by aliases, so that
+// a few nested aliases cannot expand into gigabytes ("billion laughs").
+
+const yamlMaxExpansion = 1000000
+
+var (
+	yamlIntPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+func newYAMLParser(data []byte) *yamlParser {
+	return &yamlParser{
+		data:    data,
+		anchors: make(map[string]interface{}),
+		sizes:   make(map[string]int),
+	}
+}
+
+func (p *yamlParser) fail(msg string) error {
+	return newParseError(p.data, int64(p.pos), msg)
+}
+
+func (p *yamlParser) peek(off int) byte {
+	if p.pos+off >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos+off]
+}
+
+func (p *yamlParser) col() int {
+	return p.pos - p.lineStart
+}
+
+func isYAMLBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == 0
+}
+
+func (p *yamlParser) atLineEnd() bool {
+	c := p.peek(0)
+	return c == '\n' || c == '\r' || p.pos >= len(p.data)
+}
+
+func (p *yamlParser) skipNewline() {
+	if p.peek(0) == '\r' {
+		p.pos++
+	}
+	if p.peek(0) == '\n' {
+		p.pos++
+	}
+	p.lineStart = p.pos
+}
+
+func (p *yamlParser) skipSpaces() {
+	for p.peek(0) == ' ' || p.peek(0) == '\t' {
+		p.pos++
+	}
+}
+
+func (p *yamlParser) skipComment() {
+	if p.peek(0) != '#' {
+		return
+	}
+	if p.pos > p.lineStart && !isYAMLBlank(p.data[p.pos-1]) {
+		return
+	}
+	for !p.atLineEnd() {
+		p.pos++
+	}
+}
+
+// skipToContent moves to the next character that is not white space,
+// a comment or a line break.
+
+func (p *yamlParser) skipToContent() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.pos >= len(p.data) || !p.atLineEnd() {
+			return
+		}
+		p.skipNewline()
+	}
+}
+
+func (p *yamlParser) atMarker(marker string) bool {
+	return p.col() == 0 && strings.HasPrefix(string(p.data[p.pos:]), marker) && isYAMLBlank(p.peek(3))
+}
+
+func (p *yamlParser) atDocumentEnd() bool {
+	return p.pos >= len(p.data) || p.atMarker("---") || p.atMarker("...")
+}
+
+func (p *yamlParser) isSeqEntry() bool {
+	return p.peek(0) == '-' && isYAMLBlank(p.peek(1))
+}
+
+func (p *yamlParser) parseStream() ([]interface{}, error) {
+	docs := []interface{}{}
+
+	for {
+		p.skipToContent()
+		for p.col() == 0 && p.peek(0) == '%' {
+			for !p.atLineEnd() {
+				p.pos++
+			}
+			p.skipToContent()
+		}
+
+		if p.pos >= len(p.data) {
+			return docs, nil
+		}
+
+		if p.atMarker("...") {
+			p.pos += 3
+			continue
+		}
+
+		if p.atMarker("---") {
+			p.pos += 3
+		}
+
+		v, err := p.parseNode(-1)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, v)
+
+		p.skipToContent()
+		if !p.atDocumentEnd() {
+			return nil, p.fail("unexpected content after document")
+		}
+		if p.atMarker("...") {
+			p.pos += 3
+		}
+	}
+}
+
+// parseNode parses a node that may start on a following line. It has to
+// be indented more than indent, otherwise the node is empty (null).
+
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	p.skipToContent()
+	if p.atDocumentEnd() || p.col() <= indent {
+		return nil, nil
+	}
+
+	return p.parseNodeHere(indent, true)
+}
+
+// parseNodeHere parses the node at the current position. indent is the
+// indentation of the enclosing block collection. allowMapping is false for
+// values on the same line as their key, where "a: b: c" is an error.
+
+func (p *yamlParser) parseNodeHere(indent int, allowMapping bool) (interface{}, error) {
+	anchor, tag, err := p.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	if anchor != "" || tag != "" {
+		p.skipComment()
+		if p.atLineEnd() {
+			v, err := p.parseNode(indent)
+			if err != nil {
+				return nil, err
+			}
+			_, isString := v.(string)
+			return p.finishNode(v, tag != "" && (v == nil || isString), anchor, tag)
+		}
+	}
+
+	var v interface{}
+	raw := false
+
+	switch c := p.peek(0); {
+	case c == '*':
+		return p.parseAlias()
+	case p.isSeqEntry():
+		if !allowMapping {
+			return nil, p.fail("block sequence is not allowed here")
+		}
+		v, err = p.parseBlockSequence(p.col())
+	case c == '|' || c == '>':
+		v, err = p.parseBlockScalar(indent)
+		raw = tag != ""
+	case c == '[' || c == '{':
+		v, err = p.parseFlow()
+	default:
+		return p.parseScalarOrMapping(indent, allowMapping, anchor, tag)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.finishNode(v, raw, anchor, tag)
+}
+
+// finishNode resolves raw scalar text according to tag and records the
+// node under anchor.
+
+func (p *yamlParser) finishNode(v interface{}, raw bool, anchor, tag string) (interface{}, error) {
+	if raw {
+		s, _ := v.(string)
+
+		var err error
+		if v, err = p.applyTag(s, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	if anchor != "" {
+		p.anchors[anchor] = v
+		p.sizes[anchor] = yamlNodeCount(v)
+	}
+
+	return v, nil
+}
+
+func (p *yamlParser) parseProperties() (anchor string, tag string, err error) {
+	for {
+		switch p.peek(0) {
+		case '&':
+			p.pos++
+			if anchor = p.readName(); anchor == "" {
+				return "", "", p.fail("missing anchor name")
+			}
+		case '!':
+			start := p.pos
+			for !isYAMLBlank(p.peek(0)) {
+				p.pos++
+			}
+			tag = string(p.data[start:p.pos])
+		default:
+			return anchor, tag, nil
+		}
+		p.skipSpaces()
+	}
+}
+
+func (p *yamlParser) readName() string {
+	start := p.pos
+	for !isYAMLBlank(p.peek(0)) && !strings.ContainsRune(",[]{}", rune(p.peek(0))) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *yamlParser) parseAlias() (interface{}, error) {
+	start := p.pos
+	p.pos++
+
+	name := p.readName()
+	v, ok := p.anchors[name]
+	if !ok {
+		p.pos = start
+		return nil, p.fail("unknown anchor " + strconv.Quote(name))
+	}
+
+	p.expanded += p.sizes[name]
+	if p.expanded > yamlMaxExpansion {
+		p.pos = start
+		return nil, p.fail("alias expansion limit exceeded")
+	}
+
+	switch t := v.(type) {
+	case *DO:
+		return t.Clone(), nil
+	case *DA:
+		return t.Clone(), nil
+	}
+
+	return v, nil
+}
+
+func yamlNodeCount(v interface{}) int {
+	n := 1
+	switch t := v.(type) {
+	case *DO:
+		for _, each := range t.Map {
+			n += yamlNodeCount(each)
+		}
+	case *DA:
+		for _, each := range t.Element {
+			n += yamlNodeCount(each)
+		}
+	}
+	return n
+}
+
+// applyTag resolves a scalar. Untagged and custom-tagged scalars follow the
+// YAML 1.2 core schema; the standard !!str, !!int, !!float, !!bool and
+// !!null tags force the type.
+
+func (p *yamlParser) applyTag(s string, tag string) (interface{}, error) {
+	switch tag {
+	case "":
+		return resolveYAMLScalar(s), nil
+	case "!", "!!str", "tag:yaml.org,2002:str":
+		return s, nil
+	}
+
+	v := resolveYAMLScalar(s)
+
+	ok := true
+	switch tag {
+	case "!!int", "tag:yaml.org,2002:int":
+		_, ok = v.(int64)
+	case "!!float", "tag:yaml.org,2002:float":
+		if i, isInt := v.(int64); isInt {
+			v = float64(i)
+		}
+		_, ok = v.(float64)
+		if !ok && isYAMLNonFinite(s) {
+			v, ok = nil, true
+		}
+	case "!!bool", "tag:yaml.org,2002:bool":
+		_, ok = v.(bool)
+	case "!!null", "tag:yaml.org,2002:null":
+		ok = v == nil
+	}
+
+	if !ok {
+		return nil, p.fail("invalid " + tag + " value " + strconv.Quote(s))
+	}
+
+	return v, nil
+}
+
+// isYAMLNonFinite reports whether s is one of the .inf and .nan forms.
+// JSON cannot hold them, so they resolve to null.
+
+func isYAMLNonFinite(s string) bool {
+	switch s {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF", "-.inf", "-.Inf", "-.INF", ".nan", ".NaN", ".NAN":
+		return true
+	}
+
+	return false
+}
+
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if isYAMLNonFinite(s) {
+		return nil
+	}
+
+	if len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'o') {
+		base := 16
+		if s[1] == 'o' {
+			base = 8
+		}
+		if u, err := strconv.ParseUint(s[2:], base, 64); err == nil {
+			if u <= math.MaxInt64 {
+				return int64(u)
+			}
+			return float64(u)
+		}
+		return s
+	}
+
+	if yamlIntPattern.MatchString(s) {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	}
+
+	if yamlFloatPattern.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return s
+}
+
+func (p *yamlParser) parseScalarOrMapping(indent int, allowMapping bool, anchor, tag string) (interface{}, error) {
+	col := p.col()
+
+	text, quoted, err := p.parseScalarLine(false)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+	if p.peek(0) == ':' && isYAMLBlank(p.peek(1)) {
+		if !allowMapping {
+			return nil, p.fail("mapping values are not allowed here")
+		}
+		if _, err := p.finishNode(text, false, anchor, ""); err != nil {
+			return nil, err
+		}
+		return p.parseBlockMapping(col, text, quoted)
+	}
+
+	if !quoted {
+		if text, err = p.continuePlain(text, indent); err != nil {
+			return nil, err
+		}
+	}
+
+	if quoted && tag == "" {
+		tag = "!"
+	}
+
+	return p.finishNode(text, true, anchor, tag)
+}
+
+// continuePlain folds the continuation lines of a multi-line plain scalar,
+// which have to be indented more than the enclosing collection.
+
+func (p *yamlParser) continuePlain(text string, indent int) (string, error) {
+	for {
+		pos, lineStart := p.pos, p.lineStart
+
+		p.skipSpaces()
+		if !p.atLineEnd() || p.pos >= len(p.data) {
+			p.pos = pos
+			return text, nil
+		}
+
+		breaks := 0
+		for {
+			p.skipNewline()
+			p.skipSpaces()
+			if !p.atLineEnd() || p.pos >= len(p.data) {
+				break
+			}
+			breaks++
+		}
+
+		if p.pos >= len(p.data) || p.col() <= indent || p.peek(0) == '#' || p.atDocumentEnd() {
+			p.pos, p.lineStart = pos, lineStart
+			return text, nil
+		}
+
+		line, _, err := p.parseScalarLine(false)
+		if err != nil {
+			return "", err
+		}
+		if p.peek(0) == ':' && isYAMLBlank(p.peek(1)) {
+			return "", p.fail("mapping values are not allowed here")
+		}
+
+		if breaks == 0 {
+			text += " " + line
+		} else {
+			text += strings.Repeat("\n", breaks) + line
+		}
+	}
+}
+
+// parseScalarLine reads a quoted scalar or the part of a plain scalar on
+// the current line. In flow context plain scalars also end at , [ ] { }.
+
+func (p *yamlParser) parseScalarLine(flow bool) (string, bool, error) {
+	switch p.peek(0) {
+	case '"':
+		s, err := p.parseDoubleQuoted()
+		return s, true, err
+	case '\'':
+		s, err := p.parseSingleQuoted()
+		return s, true, err
+	}
+
+	if c := p.peek(0); strings.IndexByte("-?:", c) >= 0 && isYAMLBlank(p.peek(1)) ||
+		strings.IndexByte(",[]{}#&*!|>%@`", c) >= 0 {
+		return "", false, p.fail("unexpected character " + strconv.QuoteRune(rune(c)))
+	}
+
+	start := p.pos
+	end := p.pos
+
+	for !p.atLineEnd() {
+		c := p.peek(0)
+		if c == ':' && (isYAMLBlank(p.peek(1)) || flow && strings.IndexByte(",[]{}", p.peek(1)) >= 0) {
+			break
+		}
+		if c == '#' && isYAMLBlank(p.data[p.pos-1]) {
+			break
+		}
+		if flow && strings.IndexByte(",[]{}", c) >= 0 {
+			break
+		}
+		p.pos++
+		if c != ' ' && c != '\t' {
+			end = p.pos
+		}
+	}
+
+	p.pos = end
+	return string(p.data[start:end]), false, nil
+}
+
+// foldLines handles a line break inside a quoted scalar: the break becomes
+// a space, or n-1 newlines when it is followed by n-1 empty lines.
+
+func (p *yamlParser) foldLines(buf []byte, keep int) []byte {
+	for len(buf) > keep && (buf[len(buf)-1] == ' ' || buf[len(buf)-1] == '\t') {
+		buf = buf[:len(buf)-1]
+	}
+
+	breaks := 0
+	for p.atLineEnd() && p.pos < len(p.data) {
+		p.skipNewline()
+		p.skipSpaces()
+		breaks++
+	}
+
+	if breaks == 1 {
+		return append(buf, ' ')
+	}
+	return append(buf, strings.Repeat("\n", breaks-1)...)
+}
+
+func (p *yamlParser) parseSingleQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+
+	var buf []byte
+	keep := 0
+
+	for {
+		if p.pos >= len(p.data) {
+			p.pos = start
+			return "", p.fail("unterminated quoted scalar")
+		}
+
+		switch c := p.peek(0); c {
+		case '\'':
+			if p.peek(1) != '\'' {
+				p.pos++
+				return string(buf), nil
+			}
+			buf = append(buf, '\'')
+			p.pos += 2
+			keep = len(buf)
+		case '\n', '\r':
+			buf = p.foldLines(buf, keep)
+			keep = len(buf)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+	'P': "\u2029",
+}
+
+func (p *yamlParser) parseDoubleQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+
+	var buf []byte
+	keep := 0
+
+	for {
+		if p.pos >= len(p.data) {
+			p.pos = start
+			return "", p.fail("unterminated quoted scalar")
+		}
+
+		switch c := p.peek(0); c {
+		case '"':
+			p.pos++
+			return string(buf), nil
+		case '\n', '\r':
+			buf = p.foldLines(buf, keep)
+			keep = len(buf)
+		case '\\':
+			p.pos++
+			e := p.peek(0)
+
+			if e == '\n' || e == '\r' {
+				// escaped line break: joined without a space
+				p.skipNewline()
+				p.skipSpaces()
+				keep = len(buf)
+				continue
+			}
+
+			if s, ok := yamlEscapes[e]; ok {
+				buf = append(buf, s...)
+				p.pos++
+				keep = len(buf)
+				continue
+			}
+
+			n := 0
+			switch e {
+			case 'x':
+				n = 2
+			case 'u':
+				n = 4
+			case 'U':
+				n = 8
+			}
+			if n == 0 || p.pos+1+n > len(p.data) {
+				return "", p.fail("invalid escape sequence")
+			}
+
+			r, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+1+n]), 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", p.fail("invalid escape sequence")
+			}
+
+			buf = utf8.AppendRune(buf, rune(r))
+			p.pos += 1 + n
+			keep = len(buf)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+func (p *yamlParser) parseBlockMapping(col int, key string, quoted bool) (*DO, error) {
+	obj := NewOrderedDO()
+	var merges []interface{}
+
+	for {
+		p.pos++ // ':'
+
+		value, err := p.parseMappingValue(col)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "<<" && !quoted {
+			merges = append(merges, value)
+		} else {
+			obj.set(key, value)
+		}
+
+		p.skipToContent()
+		if p.atDocumentEnd() || p.col() < col {
+			break
+		}
+		if p.col() > col {
+			return nil, p.fail("bad indentation of a mapping entry")
+		}
+		if p.isSeqEntry() {
+			return nil, p.fail("expected a mapping key")
+		}
+
+		if key, quoted, err = p.parseKey(); err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+		if !(p.peek(0) == ':' && isYAMLBlank(p.peek(1))) {
+			return nil, p.fail("expected ':' after mapping key")
+		}
+	}
+
+	if err := p.merge(obj, merges); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (p *yamlParser) parseKey() (string, bool, error) {
+	switch p.peek(0) {
+	case '?':
+		return "", false, p.fail("complex mapping keys are not supported")
+	case '[', '{':
+		return "", false, p.fail("non-scalar mapping keys are not supported")
+	}
+
+	anchor, _, err := p.parseProperties()
+	if err != nil {
+		return "", false, err
+	}
+
+	key, quoted, err := p.parseScalarLine(false)
+	if err != nil {
+		return "", false, err
+	}
+
+	if anchor != "" {
+		p.anchors[anchor] = key
+		p.sizes[anchor] = 1
+	}
+
+	return key, quoted, nil
+}
+
+func (p *yamlParser) parseMappingValue(col int) (interface{}, error) {
+	p.skipSpaces()
+	p.skipComment()
+
+	if !p.atLineEnd() {
+		return p.parseNodeHere(col, false)
+	}
+
+	p.skipToContent()
+	if p.atDocumentEnd() {
+		return nil, nil
+	}
+
+	// a sequence may be indented as much as its key
+	if p.col() > col || p.col() == col && p.isSeqEntry() {
+		return p.parseNodeHere(col, true)
+	}
+
+	return nil, nil
+}
+
+// merge applies "<<" merge keys. Keys of the mapping itself and of earlier
+// merged mappings take precedence.
+
+func (p *yamlParser) merge(obj *DO, merges []interface{}) error {
+	var sources []*DO
+
+	for _, each := range merges {
+		switch t := each.(type) {
+		case *DO:
+			sources = append(sources, t)
+		case *DA:
+			for _, elem := range t.Element {
+				src, ok := elem.(*DO)
+				if !ok {
+					return p.fail("merge key value is not a mapping")
+				}
+				sources = append(sources, src)
+			}
+		default:
+			return p.fail("merge key value is not a mapping")
+		}
+	}
+
+	for _, src := range sources {
+		for _, key := range src.Keys() {
+			if _, ok := obj.Map[key]; !ok {
+				obj.set(key, src.Map[key])
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *yamlParser) parseBlockSequence(col int) (*DA, error) {
+	arr := NewDA()
+
+	for {
+		p.pos++ // '-'
+		p.skipSpaces()
+		p.skipComment()
+
+		var v interface{}
+		var err error
+
+		if p.atLineEnd() {
+			v, err = p.parseNode(col)
+		} else {
+			v, err = p.parseNodeHere(col, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		arr.Element = append(arr.Element, v)
+
+		p.skipToContent()
+		if p.atDocumentEnd() || p.col() < col {
+			break
+		}
+		if p.col() > col {
+			return nil, p.fail("bad indentation of a sequence entry")
+		}
+		if !p.isSeqEntry() {
+			break
+		}
+	}
+
+	return arr, nil
+}
+
+func (p *yamlParser) parseBlockScalar(indent int) (string, error) {
+	literal := p.peek(0) == '|'
+	p.pos++
+
+	chomp := byte(0)
+	explicit := 0
+
+	for idx := 0; idx < 2; idx++ {
+		switch c := p.peek(0); {
+		case c == '+' || c == '-':
+			chomp = c
+			p.pos++
+		case c >= '1' && c <= '9':
+			explicit = int(c - '0')
+			p.pos++
+		}
+	}
+
+	p.skipSpaces()
+	p.skipComment()
+	if !p.atLineEnd() {
+		return "", p.fail("invalid block scalar header")
+	}
+	p.skipNewline()
+
+	contentIndent := indent + 1
+	if explicit > 0 {
+		contentIndent = explicit
+		if indent > 0 {
+			contentIndent += indent
+		}
+	} else {
+		// detected from the first non-empty line
+		spaces := 0
+		for idx := p.pos; idx < len(p.data); idx++ {
+			if c := p.data[idx]; c == ' ' {
+				spaces++
+				continue
+			} else if c == '\n' || c == '\r' {
+				spaces = 0
+				continue
+			}
+			if spaces > indent {
+				contentIndent = spaces
+			}
+			break
+		}
+	}
+
+	var lines []string
+
+	for p.pos < len(p.data) {
+		if p.atMarker("---") || p.atMarker("...") {
+			break
+		}
+
+		n := 0
+		for p.peek(n) == ' ' {
+			n++
+		}
+
+		if c := p.peek(n); (c == '\n' || c == '\r' || p.pos+n >= len(p.data)) && n <= contentIndent {
+			lines = append(lines, "")
+		} else if n < contentIndent {
+			break
+		} else {
+			start := p.pos + contentIndent
+			for !p.atLineEnd() {
+				p.pos++
+			}
+			lines = append(lines, string(p.data[start:p.pos]))
+		}
+
+		for !p.atLineEnd() {
+			p.pos++
+		}
+		p.skipNewline()
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if literal {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = foldYAMLLines(lines)
+	}
+
+	if len(lines) > 0 && chomp != '-' {
+		text += "\n"
+	}
+	if chomp == '+' {
+		text += strings.Repeat("\n", trailing)
+	}
+
+	return text, nil
+}
+
+// foldYAMLLines joins the lines of a folded block scalar. Lines are joined
+// with a space, empty lines become line breaks and more-indented lines keep
+// their line breaks.
+
+func foldYAMLLines(lines []string) string {
+	var sb strings.Builder
+
+	breaks := 0
+	started := false
+	prevMore := false
+
+	for _, line := range lines {
+		if line == "" {
+			breaks++
+			continue
+		}
+
+		more := line[0] == ' ' || line[0] == '\t'
+
+		switch {
+		case !started:
+			sb.WriteString(strings.Repeat("\n", breaks))
+		case more || prevMore:
+			sb.WriteString(strings.Repeat("\n", breaks+1))
+		case breaks == 0:
+			sb.WriteByte(' ')
+		default:
+			sb.WriteString(strings.Repeat("\n", breaks))
+		}
+
+		sb.WriteString(line)
+		breaks = 0
+		started = true
+		prevMore = more
+	}
+
+	return sb.String()
+}
+
+func (p *yamlParser) skipFlowSpace() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.pos >= len(p.data) || !p.atLineEnd() {
+			return
+		}
+		p.skipNewline()
+	}
+}
+
+func (p *yamlParser) parseFlow() (interface{}, error) {
+	start := p.pos
+	open := p.peek(0)
+	p.pos++
+
+	var obj *DO
+	var arr *DA
+	var merges []interface{}
+
+	closing := byte(']')
+	if open == '{' {
+		closing = '}'
+		obj = NewOrderedDO()
+	} else {
+		arr = NewDA()
+	}
+
+	for {
+		p.skipFlowSpace()
+		if p.pos >= len(p.data) {
+			p.pos = start
+			return nil, p.fail("unterminated flow collection")
+		}
+		if p.peek(0) == closing {
+			p.pos++
+			break
+		}
+
+		keyStart := p.pos
+		key, err := p.parseFlowNode()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipFlowSpace()
+
+		var value interface{}
+		pair := p.peek(0) == ':'
+
+		if pair {
+			p.pos++
+			p.skipFlowSpace()
+			if c := p.peek(0); c != ',' && c != closing {
+				if value, err = p.parseFlowNode(); err != nil {
+					return nil, err
+				}
+				p.skipFlowSpace()
+			}
+		}
+
+		if obj != nil || pair {
+			keyText, ok := p.flowKey(key, keyStart)
+			if !ok {
+				p.pos = keyStart
+				return nil, p.fail("non-scalar mapping keys are not supported")
+			}
+
+			if obj == nil {
+				single := NewOrderedDO()
+				single.set(keyText, value)
+				arr.Element = append(arr.Element, single)
+			} else if keyText == "<<" && p.data[keyStart] != '"' && p.data[keyStart] != '\'' {
+				merges = append(merges, value)
+			} else {
+				obj.set(keyText, value)
+			}
+		} else {
+			arr.Element = append(arr.Element, key)
+		}
+
+		switch p.peek(0) {
+		case ',':
+			p.pos++
+		case closing:
+		default:
+			return nil, p.fail("expected ',' or '" + string(closing) + "'")
+		}
+	}
+
+	if obj == nil {
+		return arr, nil
+	}
+
+	if err := p.merge(obj, merges); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// flowKey returns the text of a scalar key as written, so that 1: and
+// true: give the keys "1" and "true".
+
+func (p *yamlParser) flowKey(key interface{}, start int) (string, bool) {
+	switch key.(type) {
+	case *DO, *DA:
+		return "", false
+	}
+
+	if c := p.data[start]; c == '"' || c == '\'' || c == '&' || c == '!' || c == '*' {
+		s, ok := key.(string)
+		return s, ok
+	}
+
+	end := start
+	for end < len(p.data) && !strings.ContainsRune(":,[]{}\n\r", rune(p.data[end])) {
+		end++
+	}
+
+	return strings.TrimRight(string(p.data[start:end]), " \t"), true
+}
+
+func (p *yamlParser) parseFlowNode() (interface{}, error) {
+	anchor, tag, err := p.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+	p.skipFlowSpace()
+
+	var v interface{}
+	raw := true
+
+	switch p.peek(0) {
+	case '*':
+		return p.parseAlias()
+	case '[', '{':
+		v, err = p.parseFlow()
+		raw = false
+	case ',', ']', '}', ':':
+		v = ""
+	default:
+		var quoted bool
+		v, quoted, err = p.parseScalarLine(true)
+		if quoted && tag == "" {
+			tag = "!"
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.finishNode(v, raw, anchor, tag)
+}
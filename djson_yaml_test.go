@@ -0,0 +1,273 @@
+package djson
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc := `# deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels: {app: web, tier: "frontend"}
+spec:
+  replicas: 3
+  paused: false
+  ratio: 0.5
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.25   # pinned
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        args: [--port, "8080", 0x1F]
+        env:
+        - name: EMPTY
+          value:
+        - name: VERSION
+          value: '1.0'
+`
+
+	aJson, err := New().ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","labels":{"app":"web","tier":"frontend"}},` +
+		`"spec":{"replicas":3,"paused":false,"ratio":0.5,"selector":{"matchLabels":{"app":"web"}},` +
+		`"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.25","ports":[{"containerPort":80,"protocol":"TCP"}],` +
+		`"args":["--port","8080",31],"env":[{"name":"EMPTY","value":null},{"name":"VERSION","value":"1.0"}]}]}}}}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if result := aJson.TypePath(`["spec"]["ratio"]`); result != "float" {
+		t.Errorf("Expected float, but got %s", result)
+	}
+
+	if result := aJson.TypePath(`["spec"]["replicas"]`); result != "int" {
+		t.Errorf("Expected int, but got %s", result)
+	}
+}
+
+func TestParseYAMLScalars(t *testing.T) {
+	cases := []struct {
+		doc      string
+		expected string
+	}{
+		{"~", `null`},
+		{"", `null`},
+		{"True", `true`},
+		{"yes", `"yes"`},
+		{"-12", `-12`},
+		{"0o17", `15`},
+		{"1e3", `1000`},
+		{"!!str 12", `"12"`},
+		{"!!float 12", `12`},
+		{`"a\tb\u00e9\x41"`, `"a\tbéA"`},
+		{"'it''s'", `"it's"`},
+		{"plain\n  folded\n\n  text", `"plain folded\ntext"`},
+		{"\"quoted\n  folded \\\n  joined\"", `"quoted folded joined"`},
+		{"[a, [b, {c: d}], e: f]", `["a",["b",{"c":"d"}],{"e":"f"}]`},
+		{"{a: 1, b: , 'c d': [], 2: x}", `{"a":1,"b":null,"c d":[],"2":"x"}`},
+		{"- - a\n  - b\n- c", `[["a","b"],"c"]`},
+		{"- a: 1\n  b: 2\n-\n  c: 3", `[{"a":1,"b":2},{"c":3}]`},
+		{"key:\n- a\n- b\nnext: 1", `{"key":["a","b"],"next":1}`},
+		{"literal: |\n  line 1\n  line 2\n\n  line 4\nnext: 1", `{"literal":"line 1\nline 2\n\nline 4\n","next":1}`},
+		{"strip: |-\n  text\n\n", `{"strip":"text"}`},
+		{"keep: |+\n  text\n\n", `{"keep":"text\n\n"}`},
+		{"folded: >\n  one\n  two\n\n  three\n    indented\n  four\n", `{"folded":"one two\nthree\n  indented\nfour\n"}`},
+		{"indent: |2\n    spaced\n  text\n", `{"indent":"  spaced\ntext\n"}`},
+		{"--- |\n  top\n", `"top\n"`},
+		{"url: http://example.com/a#b # comment", `{"url":"http://example.com/a#b"}`},
+	}
+
+	for _, each := range cases {
+		aJson, err := New().ParseYAML([]byte(each.doc))
+		if err != nil {
+			t.Errorf("Expected %s, but got %v for %q", each.expected, err, each.doc)
+			continue
+		}
+
+		if result := aJson.ToStringWith(EncodeOptions{}); result != each.expected {
+			t.Errorf("Expected %s, but got %s for %q", each.expected, result, each.doc)
+		}
+	}
+
+	aJson, err := New().ParseYAML([]byte("{a: .inf, b: -.Inf, c: 1, d: .nan, e: !!float .inf}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := aJson.ToString(); result != `{"a":null,"b":null,"c":1,"d":null,"e":null}` {
+		t.Errorf("Expected non-finite numbers as null, but got %s", result)
+	}
+}
+
+func TestParseYAMLAnchors(t *testing.T) {
+	doc := `defaults: &defaults
+  adapter: postgres
+  host: localhost
+  tags: &tags [a, b]
+development:
+  <<: *defaults
+  database: dev
+  host: db
+copy: *tags
+`
+
+	aJson, err := New().ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"defaults":{"adapter":"postgres","host":"localhost","tags":["a","b"]},` +
+		`"development":{"database":"dev","host":"db","adapter":"postgres","tags":["a","b"]},"copy":["a","b"]}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	// aliases are copies
+	aJson._Object.Map["copy"].(*DA).ReplaceAt(0, "z")
+	if result := aJson.StringPath(`["defaults"]["tags"][0]`); result != "a" {
+		t.Errorf("Expected a, but got %s", result)
+	}
+
+	bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	prev := "a"
+	for _, name := range []string{"b", "c", "d", "e", "f", "g", "h"} {
+		bomb += name + ": &" + name + " [*" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev +
+			", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + ", *" + prev + "]\n"
+		prev = name
+	}
+
+	var pErr *ParseError
+	if _, err := New().ParseYAML([]byte(bomb)); !errors.As(err, &pErr) {
+		t.Errorf("Expected ParseError, but got %v", err)
+	}
+
+	if _, err := New().ParseYAML([]byte("a: *missing")); err == nil {
+		t.Errorf("Expected error for unknown anchor")
+	}
+}
+
+func TestParseYAMLStream(t *testing.T) {
+	doc := "%YAML 1.2\n---\na: 1\n---\n- x\n...\n--- text\n---\n"
+
+	docs, err := ParseYAMLAll([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`{"a":1}`, `["x"]`, `"text"`, `null`}
+	if len(docs) != len(expected) {
+		t.Fatalf("Expected %d documents, but got %d", len(expected), len(docs))
+	}
+
+	for idx := range docs {
+		if result := docs[idx].ToStringWith(EncodeOptions{}); result != expected[idx] {
+			t.Errorf("Expected %s, but got %s", expected[idx], result)
+		}
+	}
+
+	if _, err := New().ParseYAML([]byte(doc)); !errors.Is(err, ErrYAMLMultipleDocuments) {
+		t.Errorf("Expected ErrYAMLMultipleDocuments, but got %v", err)
+	}
+}
+
+func TestParseYAMLError(t *testing.T) {
+	cases := []string{
+		"a: b: c",
+		"a:\n  b: 1\n c: 2",
+		"[a, b",
+		"\"open",
+		"? complex\n: key",
+		"a: 1\n- b",
+	}
+
+	for _, each := range cases {
+		var pErr *ParseError
+		if _, err := New().ParseYAML([]byte(each)); !errors.As(err, &pErr) {
+			t.Errorf("Expected ParseError, but got %v for %q", err, each)
+		}
+	}
+
+	_, err := New().ParseYAML([]byte("a:\n  b: 1\n c: 2"))
+
+	var pErr *ParseError
+	if errors.As(err, &pErr) && (pErr.Line != 3 || pErr.Column != 2) {
+		t.Errorf("Expected line 3, column 2, but got line %d, column %d", pErr.Line, pErr.Column)
+	}
+}
+
+func TestToYAML(t *testing.T) {
+	aJson := New().Put(Object{
+		"name":  "web",
+		"count": 2,
+		"ratio": 1.0,
+		"empty": Object{},
+		"none":  nil,
+		"list": Array{
+			Object{"a": "1", "b": true},
+			Array{"x", "y"},
+			"multi\nline\n",
+			"- dash",
+			"yes",
+		},
+	})
+
+	expected := `count: 2
+empty: {}
+list:
+  - a: "1"
+    b: true
+  - - x
+    - "y"
+  - |
+    multi
+    line
+  - "- dash"
+  - "yes"
+name: web
+none: null
+ratio: 1.0
+`
+
+	b, err := aJson.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != expected {
+		t.Errorf("Expected %s, but got %s", expected, b)
+	}
+
+	bJson, err := New().ParseYAML(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result, expected := bJson.ToString(), aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if result := bJson.TypePath(`["ratio"]`); result != "float" {
+		t.Errorf("Expected float, but got %s", result)
+	}
+
+	b, err = ToYAMLAll(New().Put("a"), NewArray())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := string(b); result != "a\n---\n[]\n" {
+		t.Errorf("Expected a document stream, but got %s", result)
+	}
+}
@@ -0,0 +1,194 @@
+package djson
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Number is a numeric literal kept as its original text, so integers
+// beyond int64 and decimals beyond float64 survive a round trip.
+// ParseOptions.PreserveNumbers produces Number values instead of int64
+// and float64.
+
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+func (n Number) IsValid() bool {
+	if n == "" {
+		return false
+	}
+
+	p := newParser([]byte(n), ParseOptions{})
+	if _, err := p.scanNumber(); err != nil {
+		return false
+	}
+
+	return p.pos == len(n)
+}
+
+// IsInt reports whether the literal has no fraction or exponent part.
+
+func (n Number) IsInt() bool {
+	if !n.IsValid() {
+		return false
+	}
+
+	for idx := 0; idx < len(n); idx++ {
+		switch n[idx] {
+		case '.', 'e', 'E':
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n Number) Int64() (int64, error) {
+	if n.IsInt() {
+		return strconv.ParseInt(string(n), 10, 64)
+	}
+
+	if bi, ok := n.BigInt(); ok && bi.IsInt64() {
+		return bi.Int64(), nil
+	}
+
+	return 0, errors.New("not Int64")
+}
+
+func (n Number) Uint64() (uint64, error) {
+	if n.IsInt() {
+		return strconv.ParseUint(string(n), 10, 64)
+	}
+
+	if bi, ok := n.BigInt(); ok && bi.IsUint64() {
+		return bi.Uint64(), nil
+	}
+
+	return 0, errors.New("not Uint64")
+}
+
+func (n Number) Float64() (float64, error) {
+	if !n.IsValid() {
+		return 0, errors.New("not Number")
+	}
+
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// BigInt returns the exact integer value. Literals with a fraction or an
+// exponent are accepted when their value is integral, e.g. 1.0e3.
+
+func (n Number) BigInt() (*big.Int, bool) {
+	if !n.IsValid() {
+		return nil, false
+	}
+
+	if n.IsInt() {
+		return new(big.Int).SetString(string(n), 10)
+	}
+
+	bf, ok := n.BigFloat()
+	if !ok || !bf.IsInt() {
+		return nil, false
+	}
+
+	bi, _ := bf.Int(nil)
+	return bi, true
+}
+
+// BigFloat parses the literal with enough precision to hold every digit.
+
+func (n Number) BigFloat() (*big.Float, bool) {
+	if !n.IsValid() {
+		return nil, false
+	}
+
+	prec := uint(len(n)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+
+	bf, _, err := big.ParseFloat(string(n), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+
+	return bf, true
+}
+
+func getUint64Base(v interface{}) (uint64, bool) {
+	switch t := v.(type) {
+	case uint:
+		return uint64(t), true
+	case uint8:
+		return uint64(t), true
+	case uint16:
+		return uint64(t), true
+	case uint32:
+		return uint64(t), true
+	case uint64:
+		return t, true
+	case int, int8, int16, int32, int64:
+		if i, ok := getIntBase(t); ok && i >= 0 {
+			return uint64(i), true
+		}
+	case float32, float64:
+		if f, ok := getFloatBase(t); ok && f >= 0 && f < math.MaxUint64 && f == math.Trunc(f) {
+			return uint64(f), true
+		}
+	case Number:
+		if u, err := t.Uint64(); err == nil {
+			return u, true
+		}
+	}
+
+	return 0, false
+}
+
+func getBigIntBase(v interface{}) (*big.Int, bool) {
+	switch t := v.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := getUint64Base(t)
+		return new(big.Int).SetUint64(u), true
+	case int, int8, int16, int32, int64:
+		i, _ := getIntBase(t)
+		return big.NewInt(i), true
+	case float32, float64:
+		f, _ := getFloatBase(t)
+		if math.IsInf(f, 0) || math.IsNaN(f) || f != math.Trunc(f) {
+			return nil, false
+		}
+		bi, _ := big.NewFloat(f).Int(nil)
+		return bi, true
+	case Number:
+		return t.BigInt()
+	}
+
+	return nil, false
+}
+
+func getBigFloatBase(v interface{}) (*big.Float, bool) {
+	switch t := v.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		u, _ := getUint64Base(t)
+		return new(big.Float).SetUint64(u), true
+	case int, int8, int16, int32, int64:
+		i, _ := getIntBase(t)
+		return new(big.Float).SetInt64(i), true
+	case float32, float64:
+		f, _ := getFloatBase(t)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		return big.NewFloat(f), true
+	case Number:
+		return t.BigFloat()
+	}
+
+	return nil, false
+}
@@ -0,0 +1,119 @@
+package djson
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPreserveNumbers(t *testing.T) {
+	doc := `{"id":12345678901234567890123,"price":0.1,"u":18446744073709551615,"exp":1.5e3,"neg":-42,"arr":[1e999,3.14159265358979323846264338327950288]}`
+
+	aJson, err := New().ParseWith([]byte(doc), ParseOptions{Ordered: true, PreserveNumbers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !aJson.IsNumber("id") || aJson.Type("price") != "number" || !aJson.IsNumeric("u") {
+		t.Errorf("Expected number nodes, but got %s %s", aJson.Type("id"), aJson.Type("price"))
+	}
+
+	if result := aJson.ToString(); result != doc {
+		t.Errorf("Expected %s, but got %s", doc, result)
+	}
+
+	id, ok := aJson.BigInt("id")
+	expected, _ := new(big.Int).SetString("12345678901234567890123", 10)
+	if !ok || id.Cmp(expected) != 0 {
+		t.Errorf("Expected %s, but got %v", expected, id)
+	}
+
+	if result := aJson.Uint64("u"); result != 18446744073709551615 {
+		t.Errorf("Expected max uint64, but got %d", result)
+	}
+
+	if result := aJson.Int("u", int64(-1)); result != -1 {
+		t.Errorf("Expected default for int64 overflow, but got %d", result)
+	}
+
+	if exp, ok := aJson.BigInt("exp"); !ok || exp.Int64() != 1500 {
+		t.Errorf("Expected 1500, but got %v", exp)
+	}
+
+	if _, ok := aJson.BigInt("price"); ok {
+		t.Errorf("Expected 0.1 not to be an integer")
+	}
+
+	if result := aJson.Int("neg"); result != -42 {
+		t.Errorf("Expected -42, but got %d", result)
+	}
+
+	if result := aJson.Float("price"); result != 0.1 {
+		t.Errorf("Expected 0.1, but got %v", result)
+	}
+
+	pi, ok := aJson.BigFloat("arr")
+	if ok || pi != nil {
+		t.Errorf("Expected no BigFloat for an array")
+	}
+
+	arr, _ := aJson.Array("arr")
+	pi, ok = arr.BigFloat(1)
+	if !ok || pi.Text('f', 35) != "3.14159265358979323846264338327950288" {
+		t.Errorf("Expected exact pi digits, but got %v", pi)
+	}
+
+	if result := arr.String(0); result != "1e999" {
+		t.Errorf("Expected 1e999, but got %s", result)
+	}
+
+	if result := aJson.String("id"); result != "12345678901234567890123" {
+		t.Errorf("Expected literal, but got %s", result)
+	}
+
+	bJson := aJson.Clone()
+	if !bJson.Equal(aJson) || bJson.ToString() != doc {
+		t.Errorf("Expected clone to be equal, but got %s", bJson.ToString())
+	}
+}
+
+func TestNumberValue(t *testing.T) {
+	aJson := New().Put(Number("98765432109876543210"))
+
+	if !aJson.IsNumber() || aJson.ToString() != "98765432109876543210" {
+		t.Errorf("Expected number, but got %s", aJson.ToString())
+	}
+
+	bJson := NewObject("n", Number("1.10"), "bad", Number("abc"))
+	if bJson.ToString() != "" {
+		t.Errorf("Expected invalid Number to fail encoding, but got %s", bJson.ToString())
+	}
+
+	bJson.Remove("bad")
+	if result := bJson.ToString(); result != `{"n":1.10}` {
+		t.Errorf("Expected {\"n\":1.10}, but got %s", result)
+	}
+
+	cJson := New().Put(Object{"u": uint64(18446744073709551615)})
+	if result := cJson.Uint64("u"); result != 18446744073709551615 {
+		t.Errorf("Expected max uint64, but got %d", result)
+	}
+
+	if result := cJson.ToString(); result != `{"u":18446744073709551615}` {
+		t.Errorf("Expected exact uint64, but got %s", result)
+	}
+}
+
+func TestValidatePreservedNumber(t *testing.T) {
+	dv := NewValidator()
+	dv.Compile(`{"type": "OBJECT", "object": {"age": {"type": "INT", "min": 1, "max": 150}, "score": "FLOAT"}}`)
+
+	aJson, _ := New().ParseWith([]byte(`{"age": 30, "score": 4.5}`), ParseOptions{PreserveNumbers: true})
+	if !dv.IsValid(aJson) {
+		t.Errorf("Expected preserved numbers to validate")
+	}
+
+	bJson, _ := New().ParseWith([]byte(`{"age": 30.5, "score": 4.5}`), ParseOptions{PreserveNumbers: true})
+	if dv.IsValid(bJson) {
+		t.Errorf("Expected a fractional age to fail")
+	}
+}
@@ -1,15 +1,20 @@
 package djson
 
 import (
+	"io"
 	"math"
+	"math/big"
 	"reflect"
+	"sort"
 
 	"github.com/goccy/go-json"
 	"github.com/volatiletech/null/v8"
 )
 
 type DO struct {
-	Map map[string]interface{}
+	Map     map[string]interface{}
+	order   []string
+	ordered bool
 }
 
 func NewDO() *DO {
@@ -18,7 +23,88 @@ func NewDO() *DO {
 	}
 }
 
+// An ordered DO remembers the insertion order of its keys and keeps it in
+// Keys, ToString and ToStringPretty instead of sorting them.
+
+func NewOrderedDO() *DO {
+	return &DO{
+		Map:     make(map[string]interface{}),
+		order:   make([]string, 0),
+		ordered: true,
+	}
+}
+
+func (m *DO) IsOrdered() bool {
+	return m.ordered
+}
+
+// Keys returns insertion order for an ordered DO and sorted keys otherwise.
+// Keys written to Map directly come last, sorted.
+
+func (m *DO) Keys() []string {
+	if !m.ordered {
+		return sortedKeys(m.Map)
+	}
+
+	keys := make([]string, 0, len(m.Map))
+	seen := make(map[string]bool, len(m.order))
+
+	for _, k := range m.order {
+		if _, ok := m.Map[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+
+	if len(keys) < len(m.Map) {
+		rest := make([]string, 0)
+		for k := range m.Map {
+			if !seen[k] {
+				rest = append(rest, k)
+			}
+		}
+		sort.Strings(rest)
+		keys = append(keys, rest...)
+	}
+
+	return keys
+}
+
+func (m *DO) removeOrder(key string) {
+	for idx := range m.order {
+		if m.order[idx] == key {
+			m.order = append(m.order[:idx], m.order[idx+1:]...)
+			return
+		}
+	}
+}
+
 func (m *DO) Put(key string, value interface{}) *DO {
+	_, exist := m.Map[key]
+
+	m.put(key, value)
+
+	if m.ordered && !exist {
+		if _, ok := m.Map[key]; ok {
+			m.order = append(m.order, key)
+		}
+	}
+
+	return m
+}
+
+// set stores an already decoded value as is, without the normalization
+// Put does. The decoders reject NaN and infinite floats or turn them into
+// null, so they never reach set.
+
+func (m *DO) set(key string, value interface{}) {
+	if _, ok := m.Map[key]; !ok && m.ordered {
+		m.order = append(m.order, key)
+	}
+	m.Map[key] = value
+}
+
+func (m *DO) put(key string, value interface{}) *DO {
 
 	if IsFloatType(value) {
 		switch t := value.(type) {
@@ -137,6 +223,8 @@ func (m *DO) Put(key string, value interface{}) *DO {
 		} else {
 			m.Map[key] = float64(0.0)
 		}
+	case Number:
+		m.Map[key] = t
 	case DO:
 		m.Map[key] = &t
 	case DA:
@@ -316,6 +404,8 @@ func (m *DO) Type(key string) (string, bool) {
 		return "int", true
 	case float32, float64:
 		return "float", true
+	case Number:
+		return "number", true
 	case string:
 		return "string", true
 	case bool:
@@ -366,6 +456,33 @@ func (m *DO) Int(key string) (int64, bool) {
 	return 0, false
 }
 
+func (m *DO) Uint64(key string) (uint64, bool) {
+	value, ok := m.Map[key]
+	if !ok {
+		return 0, false
+	}
+
+	return getUint64Base(value)
+}
+
+func (m *DO) BigInt(key string) (*big.Int, bool) {
+	value, ok := m.Map[key]
+	if !ok {
+		return nil, false
+	}
+
+	return getBigIntBase(value)
+}
+
+func (m *DO) BigFloat(key string) (*big.Float, bool) {
+	value, ok := m.Map[key]
+	if !ok {
+		return nil, false
+	}
+
+	return getBigFloatBase(value)
+}
+
 func (m *DO) Object(key string) (*DO, bool) {
 	value, ok := m.Map[key]
 	if !ok {
@@ -407,22 +524,41 @@ func (m *DO) Array(key string) (*DA, bool) {
 func (m *DO) Remove(keys ...string) *DO {
 	for idx := range keys {
 		delete(m.Map, keys[idx])
+		if m.ordered {
+			m.removeOrder(keys[idx])
+		}
 	}
 	return m
 }
 
 func (m *DO) ToStringPretty() string {
-	jsonByte, _ := json.MarshalIndent(ObjectToMap(m), "", "   ")
-	return string(jsonByte)
+	e := newEncoder()
+	e.indent = "   "
+	if err := e.encodeObject(m, 0); err != nil {
+		return ""
+	}
+	return string(e.buf)
 }
 
 func (m *DO) ToString() string {
-	jsonByte, err := json.Marshal(ObjectToMap(m))
-	if err != nil {
+	e := newEncoder()
+	if err := e.encodeObject(m, 0); err != nil {
 		// log.Println(err)
 		return ""
 	}
-	return string(jsonByte)
+	return string(e.buf)
+}
+
+func (m *DO) ToStringWith(opts EncodeOptions) string {
+	b, err := encodeWith(m, opts)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (m *DO) Encode(w io.Writer, opts EncodeOptions) error {
+	return writeEncoded(w, m, opts)
 }
 
 func (m *DO) Len() int {
@@ -475,6 +611,10 @@ func (m *DO) Equal(t *DO) bool {
 			if mFloat != tFloat {
 				return false
 			}
+		case Number:
+			if m.Map[i].(Number) != t.Map[i].(Number) {
+				return false
+			}
 		case *DO:
 			mdo := m.Map[i].(*DO)
 			tdo := t.Map[i].(*DO)
@@ -508,6 +648,11 @@ func (m *DO) Clone() *DO {
 
 	t.Map = make(map[string]interface{})
 
+	if m.ordered {
+		t.ordered = true
+		t.order = append(make([]string, 0, len(m.order)), m.order...)
+	}
+
 	for k := range m.Map {
 
 		if m.Map[k] == nil {
@@ -524,6 +669,8 @@ func (m *DO) Clone() *DO {
 			t.Map[k], _ = m.Int(k)
 		case float64:
 			t.Map[k], _ = m.Float(k)
+		case Number:
+			t.Map[k] = m.Map[k].(Number)
 		case *DO:
 			mdo := m.Map[k].(*DO)
 			t.Map[k] = mdo.Clone()
@@ -544,6 +691,18 @@ func (m *DO) Rename(from, to string) bool {
 		return false
 	}
 
+	if m.ordered {
+		if _, ok := m.Map[to]; ok {
+			m.removeOrder(to)
+		}
+		for idx := range m.order {
+			if m.order[idx] == from {
+				m.order[idx] = to
+				break
+			}
+		}
+	}
+
 	m.Map[to] = m.Map[from]
 	delete(m.Map, from)
 
@@ -0,0 +1,80 @@
+package djson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderedParse(t *testing.T) {
+	doc := `{"zeta": 1, "alpha": {"y": true, "x": null}, "mid": [{"b": 1, "a": 2}], "beta": "x"}`
+
+	aJson, err := New().ParseWith([]byte(doc), ParseOptions{Ordered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"zeta":1,"alpha":{"y":true,"x":null},"mid":[{"b":1,"a":2}],"beta":"x"}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	if result := strings.Join(aJson.GetKeys(), ","); result != "zeta,alpha,mid,beta" {
+		t.Errorf("Expected zeta,alpha,mid,beta, but got %s", result)
+	}
+
+	keys, _ := aJson.KeysPath(`["mid"][0]`)
+	if result := strings.Join(keys, ","); result != "b,a" {
+		t.Errorf("Expected b,a, but got %s", result)
+	}
+
+	expected = "{\n   \"zeta\": 1,\n   \"alpha\": {\n      \"y\": true,\n      \"x\": null\n   },\n   \"mid\": [\n      {\n         \"b\": 1,\n         \"a\": 2\n      }\n   ],\n   \"beta\": \"x\"\n}"
+	obj, _ := aJson.Object()
+	if result := obj._Object.ToStringPretty(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	unordered, _ := New().ParseE(doc)
+	expected = `{"alpha":{"x":null,"y":true},"beta":"x","mid":[{"a":2,"b":1}],"zeta":1}`
+	if result := unordered.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+}
+
+func TestOrderedEdit(t *testing.T) {
+	aJson := NewOrderedObject("c", 1, "b", 2, "a", 3)
+
+	aJson.Put("d", 4)
+	aJson.Put("b", 20) // keeps its position
+	aJson.Remove("c")
+	aJson.Rename("a", "z")
+
+	expected := `{"b":20,"z":3,"d":4}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	aJson.Rename("b", "d") // replaces the existing d at b's position
+
+	expected = `{"d":20,"z":3}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+
+	bJson := aJson.Clone()
+	bJson.Put("y", 5)
+
+	if result := bJson.ToString(); result != `{"d":20,"z":3,"y":5}` {
+		t.Errorf("Expected cloned order, but got %s", result)
+	}
+
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected clone to be independent, but got %s", result)
+	}
+
+	aJson._Object.Map["direct"] = 1 // bypasses ordering, comes last
+
+	expected = `{"d":20,"z":3,"direct":1}`
+	if result := aJson.ToString(); result != expected {
+		t.Errorf("Expected %s, but got %s", expected, result)
+	}
+}
@@ -1,11 +1,12 @@
 package djson
 
 import (
-	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 
 	gov "github.com/asaskevich/govalidator"
 	"github.com/goccy/go-json"
@@ -97,6 +98,10 @@ func getStringBase(v interface{}) (string, bool) {
 		return "nil", true
 	}
 
+	if n, ok := v.(Number); ok {
+		return string(n), true
+	}
+
 	if IsInTypes(v, "string", "bool", "float32", "float64") {
 		return fmt.Sprintf("%v", v), true
 	}
@@ -136,6 +141,11 @@ func getBoolBase(v interface{}) (bool, bool) {
 }
 
 func getFloatBase(v interface{}) (float64, bool) {
+	if n, ok := v.(Number); ok {
+		floatVal, err := n.Float64()
+		return floatVal, err == nil
+	}
+
 	if floatVal, err := gov.ToFloat(v); err != nil {
 		return 0, false
 	} else {
@@ -144,6 +154,29 @@ func getFloatBase(v interface{}) (float64, bool) {
 }
 
 func getIntBase(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case uint:
+		if uint64(t) > math.MaxInt64 {
+			return 0, false
+		}
+	case uint64:
+		if t > math.MaxInt64 {
+			return 0, false
+		}
+	case Number:
+		if t.IsInt() {
+			intVal, err := t.Int64()
+			return intVal, err == nil
+		}
+
+		floatVal, err := t.Float64()
+		if err != nil || floatVal >= math.MaxInt64 || floatVal < math.MinInt64 {
+			return 0, false
+		}
+
+		return int64(floatVal), true
+	}
+
 	if intVal, err := gov.ToInt(v); err != nil {
 		return 0, false
 	} else {
@@ -195,30 +228,11 @@ func IsInTypes(v interface{}, types ...string) bool {
 }
 
 func ParseToObject(doc string) (*DO, error) {
-	var data map[string]interface{}
-
-	d := json.NewDecoder(strings.NewReader(doc))
-	d.UseNumber()
-
-	if err := d.Decode(&data); err != nil {
-		return nil, errors.New("not Object")
-	}
-
-	return ParseObject(data), nil
-
+	return ParseBytesToObject([]byte(doc))
 }
 
 func ParseToArray(doc string) (*DA, error) {
-	var data []interface{}
-
-	d := json.NewDecoder(strings.NewReader(doc))
-	d.UseNumber()
-
-	if err := d.Decode(&data); err != nil {
-		return nil, errors.New("not Array")
-	}
-
-	return ParseArray(data), nil
+	return ParseBytesToArray([]byte(doc))
 }
 
 func ParseObject(data map[string]interface{}) *DO {
@@ -291,34 +305,78 @@ func ParseArray(data []interface{}) *DA {
 	return arr
 }
 
+// PathTokenizer splits a path into keys and indexes. Brackets such as
+// ["user"][0] and dots such as user.tags.0 can be mixed, e.g.
+// items[2].sku; a backslash makes the next character part of a dotted
+// key, so a\.b is the single key "a.b".
+
 func PathTokenizer(path string) []interface{} {
+	tokens, _ := tokenizePath(path)
+	return tokens
+}
+
+// tokenizePath is PathTokenizer that also reports an unclosed bracket or
+// quote, a trailing backslash and text after a closing bracket that does
+// not start a new key. Whitespace between keys is ignored. The tokens are
+// returned either way.
+
+func tokenizePath(path string) ([]interface{}, error) {
 	rstack := NewRuneStack()
 	token := make([]rune, 0)
+	spaces := make([]rune, 0)
 	inTokens := make([]string, 0)
 
 	prev := rune(0)
 	var depthL int
+	var escaped, afterBracket, trailing bool
+
+	flush := func() {
+		if len(token) > 0 {
+			inTokens = append(inTokens, string(token))
+			token = make([]rune, 0)
+		}
+		spaces = spaces[:0]
+	}
+
+	appendKey := func(each rune) {
+		if afterBracket {
+			trailing = true
+			return
+		}
+		token = append(append(token, spaces...), each)
+		spaces = spaces[:0]
+	}
 
 	for _, each := range path {
 
 		peek := rstack.Peek()
 
 		if depthL == 0 {
-			if each == '[' && prev != '\\' {
+			if escaped {
+				appendKey(each)
+				escaped = false
+			} else if each == '\\' {
+				escaped = true
+			} else if each == '[' {
+				flush()
 				rstack.Push(each)
-				token = make([]rune, 0)
 				depthL = 1
+			} else if each == '.' {
+				flush()
+				afterBracket = false
+			} else if unicode.IsSpace(each) {
+				if len(token) > 0 {
+					spaces = append(spaces, each)
+				}
 			} else {
-				token = append(token, each)
+				appendKey(each)
 			}
 		} else if depthL == 1 {
 			if peek == '[' && each == ']' && prev != '\\' {
-				if len(token) > 0 {
-					inTokens = append(inTokens, string(token))
-					token = make([]rune, 0)
-				}
+				flush()
 				rstack.Pop()
 				depthL = 0
+				afterBracket = true
 			} else if (each == '"' || each == '\'') && prev != '\\' {
 				rstack.Push(each)
 				depthL = 2
@@ -328,10 +386,7 @@ func PathTokenizer(path string) []interface{} {
 		} else if depthL == 2 {
 
 			if (peek == '"' && each == '"' && prev != '\\') || (peek == '\'' && each == '\'' && prev != '\\') {
-				if len(token) > 0 {
-					inTokens = append(inTokens, string(token))
-					token = make([]rune, 0)
-				}
+				flush()
 				rstack.Pop()
 				depthL = 1
 			} else {
@@ -342,6 +397,13 @@ func PathTokenizer(path string) []interface{} {
 		prev = each
 	}
 
+	var err error
+	if depthL != 0 || escaped || trailing {
+		err = fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	} else {
+		flush()
+	}
+
 	outTokens := make([]interface{}, 0)
 	for idx := range inTokens {
 		if intVal, err := strconv.Atoi(inTokens[idx]); err == nil {
@@ -351,7 +413,26 @@ func PathTokenizer(path string) []interface{} {
 		}
 	}
 
-	return outTokens
+	return outTokens, err
+}
+
+// formatPath is the inverse of PathTokenizer, e.g. ["items"][0]["id"].
+
+func formatPath(tokens []interface{}) string {
+	var sb strings.Builder
+
+	for _, each := range tokens {
+		sb.WriteByte('[')
+		switch t := each.(type) {
+		case int:
+			sb.WriteString(strconv.Itoa(t))
+		case string:
+			sb.WriteString(strconv.Quote(t))
+		}
+		sb.WriteByte(']')
+	}
+
+	return sb.String()
 }
 
 func MustSome(opt *JSON, keys ...interface{}) bool {
@@ -11,6 +11,34 @@ func TestTokenizer(t *testing.T) {
 	log.Println(PathTokenizer(`["a'a"][1][b]b]`)) // [a'a 1 b]
 }
 
+func TestTokenizerDots(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{`user.profile.name`, `["user"]["profile"]["name"]`},
+		{`items[2].sku`, `["items"][2]["sku"]`},
+		{`items.2.sku`, `["items"][2]["sku"]`},
+		{`[0].name`, `[0]["name"]`},
+		{`a\.b.c`, `["a.b"]["c"]`},
+		{`a\\.b`, `["a\\"]["b"]`},
+		{`data["x.y"][1][z]`, `["data"]["x.y"][1]["z"]`},
+		{`.a..b.`, `["a"]["b"]`},
+		{`["aa"][1][b_b]`, `["aa"][1]["b_b"]`},
+		{`["a'a"][1][b]b]`, `["a'a"][1]["b"]`},
+		{` ["a"]`, `["a"]`},
+		{` user . first name [0] `, `["user"]["first name"][0]`},
+		{`a[0]junk`, `["a"][0]`},
+		{``, ``},
+	}
+
+	for _, each := range cases {
+		if result := formatPath(PathTokenizer(each.path)); result != each.expected {
+			t.Errorf("Expected %s, but got %s for %s", each.expected, result, each.path)
+		}
+	}
+}
+
 func TestParse(t *testing.T) {
 	doc := `[[1,2,3]]`
 	tdjson := New().Parse(doc)
@@ -587,6 +587,19 @@ func CheckVItem(vi *VItem, tjson *JSON) bool {
 		vtype = tjson.Type(vi.Name)
 	}
 
+	if vtype == "number" { // preserved numbers are checked as int or float
+		ejson := tjson
+		if vi.Name != "__root__" && vi.Name != "__array__" {
+			ejson, _ = tjson.Get(vi.Name)
+		}
+
+		if ejson != nil && ejson._Number.IsInt() {
+			vtype = "int"
+		} else {
+			vtype = "float"
+		}
+	}
+
 	//log.Println("CheckVItem ", vi.Name, " ", vtype, " ", vi.Type, " ", tjson.ToString())
 
 	if vtype == "" && !vi.IsRequred {